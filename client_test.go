@@ -762,6 +762,55 @@ func TestUpdateItemError(t *testing.T) {
 	c.Nil(output)
 }
 
+func TestUpdateItemInvalidSet(t *testing.T) {
+	c := require.New(t)
+
+	client := setupClient(tableName)
+
+	err := ensurePokemonTable(client)
+	c.NoError(err)
+
+	err = createPokemon(client, pokemon{ID: "001", Type: "grass", Name: "Bulbasaur"})
+	c.NoError(err)
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String("001"),
+			},
+		},
+		UpdateExpression: aws.String("SET #name = #name + :suffix"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":suffix": {S: aws.String("saur")},
+		},
+		ExpressionAttributeNames: map[string]*string{
+			"#name": aws.String("name"),
+		},
+	}
+
+	var output *dynamodb.UpdateItemOutput
+
+	c.NotPanics(func() {
+		output, err = client.UpdateItem(input)
+	})
+	c.Nil(output)
+
+	var aerr awserr.Error
+	c.True(errors.As(err, &aerr))
+	c.Equal("ValidationException", aerr.Code())
+	c.Equal("Invalid expression: an operand in the update expression has an incorrect data type: S + S", aerr.Message())
+
+	item, err := client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {S: aws.String("001")},
+		},
+	})
+	c.NoError(err)
+	c.Equal("Bulbasaur", aws.StringValue(item.Item["name"].S))
+}
+
 func TestQueryWithContext(t *testing.T) {
 	c := require.New(t)
 	client := setupClient(tableName)
@@ -991,6 +1040,55 @@ func TestScanWithContext(t *testing.T) {
 	}
 }
 
+func TestScanWithContextPartitionKeyFilter(t *testing.T) {
+	c := require.New(t)
+
+	client := setupClient(tableName)
+
+	err := ensurePokemonTable(client)
+	c.NoError(err)
+
+	for _, creature := range []pokemon{
+		{ID: "001", Type: "grass", Name: "Bulbasaur"},
+		{ID: "004", Type: "fire", Name: "Charmander"},
+		{ID: "007", Type: "water", Name: "Squirtle"},
+	} {
+		err = createPokemon(client, creature)
+		c.NoError(err)
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String(tableName),
+		FilterExpression: aws.String("#id = :id AND #type = :type"),
+		ExpressionAttributeNames: map[string]*string{
+			"#id":   aws.String("id"),
+			"#type": aws.String("type"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":id":   {S: aws.String("004")},
+			":type": {S: aws.String("fire")},
+		},
+	}
+
+	out, err := client.ScanWithContext(context.Background(), input)
+	c.NoError(err)
+	c.Len(out.Items, 1)
+	c.Equal("Charmander", aws.StringValue(out.Items[0]["name"].S))
+
+	input.FilterExpression = aws.String("#id = :id OR #type = :type")
+	input.ExpressionAttributeValues[":type"] = &dynamodb.AttributeValue{S: aws.String("water")}
+
+	out, err = client.ScanWithContext(context.Background(), input)
+	c.NoError(err)
+	c.Len(out.Items, 2)
+
+	input.FilterExpression = aws.String("#id = :id AND #type = :type")
+
+	out, err = client.ScanWithContext(context.Background(), input)
+	c.NoError(err)
+	c.Empty(out.Items)
+}
+
 func TestDeleteItemWithContext(t *testing.T) {
 	c := require.New(t)
 
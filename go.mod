@@ -1,6 +1,6 @@
 module github.com/truora/minidyn
 
-go 1.17
+go 1.20
 
 require (
 	github.com/aws/aws-sdk-go v1.40.12
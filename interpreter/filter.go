@@ -0,0 +1,62 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/truora/minidyn/interpreter/language"
+)
+
+// FilterLimit returns up to limit items matching the filter expression, in the same order,
+// and the position where the evaluation stopped: the index of the item after the last
+// evaluated one, len(items) when all the items were evaluated. A limit lower than 1 means no limit
+func FilterLimit(expr string, items []map[string]*dynamodb.AttributeValue, names map[string]*string, values map[string]*dynamodb.AttributeValue, limit int) ([]map[string]*dynamodb.AttributeValue, int, error) {
+	l := language.NewLexer(expr)
+	p := language.NewParser(l)
+	program := p.ParseDynamoExpression()
+
+	if err := parserError(p); err != nil {
+		return nil, 0, err
+	}
+
+	err := validatePlaceholders(program, names, values)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the placeholder values are the same for every item
+	program = language.PrecomputeIn(program, values)
+	aliases := expressionAliases(names)
+
+	matches := []map[string]*dynamodb.AttributeValue{}
+
+	for i, item := range items {
+		env := language.NewEnvironment()
+		env.Aliases = aliases
+
+		err := env.AddAttributes(item)
+		if err != nil {
+			return nil, i, fmt.Errorf("%w: %s", ErrUnsupportedFeature, err.Error())
+		}
+
+		err = env.AddAttributes(values)
+		if err != nil {
+			return nil, i, fmt.Errorf("%w: %s", ErrUnsupportedFeature, err.Error())
+		}
+
+		result := language.Eval(program, env)
+		if result.Type() == language.ObjectTypeError {
+			return nil, i, fmt.Errorf("%w: %s", ErrSyntaxError, result.Inspect())
+		}
+
+		if result == language.TRUE {
+			matches = append(matches, item)
+		}
+
+		if limit > 0 && len(matches) == limit {
+			return matches, i + 1, nil
+		}
+	}
+
+	return matches, len(items), nil
+}
@@ -0,0 +1,73 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestFilterLimit(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{
+		{"id": {S: aws.String("1")}, "kind": {S: aws.String("a")}},
+		{"id": {S: aws.String("2")}, "kind": {S: aws.String("b")}},
+		{"id": {S: aws.String("3")}, "kind": {S: aws.String("a")}},
+		{"id": {S: aws.String("4")}, "kind": {S: aws.String("a")}},
+		{"id": {S: aws.String("5")}, "kind": {S: aws.String("b")}},
+	}
+
+	names := map[string]*string{"#k": aws.String("kind")}
+	values := map[string]*dynamodb.AttributeValue{":kind": {S: aws.String("a")}}
+
+	tests := []struct {
+		name     string
+		limit    int
+		expected []string
+		stopped  int
+	}{
+		{"smaller than the matches", 2, []string{"1", "3"}, 3},
+		{"equal to the matches", 3, []string{"1", "3", "4"}, 4},
+		{"larger than the matches", 10, []string{"1", "3", "4"}, 5},
+		{"no limit", 0, []string{"1", "3", "4"}, 5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			matches, stopped, err := FilterLimit("#k = :kind", items, names, values, tc.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if stopped != tc.stopped {
+				t.Errorf("wrong stop position; expected=%d, got=%d", tc.stopped, stopped)
+			}
+
+			if len(matches) != len(tc.expected) {
+				t.Fatalf("wrong number of matches; expected=%d, got=%d", len(tc.expected), len(matches))
+			}
+
+			for i, id := range tc.expected {
+				if actual := aws.StringValue(matches[i]["id"].S); actual != id {
+					t.Errorf("wrong match at %d; expected=%s, got=%s", i, id, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterLimitErrors(t *testing.T) {
+	_, _, err := FilterLimit("a =", nil, nil, nil, 1)
+	if !errors.Is(err, ErrSyntaxError) {
+		t.Errorf("unexpected error; expected=%v, got=%v", ErrSyntaxError, err)
+	}
+
+	items := []map[string]*dynamodb.AttributeValue{
+		{"n": {S: aws.String("1")}},
+	}
+
+	_, stopped, err := FilterLimit("n > :n", items, nil, map[string]*dynamodb.AttributeValue{":n": {N: aws.String("1")}}, 1)
+	if !errors.Is(err, ErrSyntaxError) || stopped != 0 {
+		t.Errorf("unexpected error; expected=%v at 0, got=%v at %d", ErrSyntaxError, err, stopped)
+	}
+}
@@ -2,8 +2,11 @@ package interpreter
 
 import (
 	"errors"
+	"strings"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/truora/minidyn/interpreter/language"
 )
 
 var (
@@ -41,6 +44,7 @@ type UpdateInput struct {
 	Expression string
 	Item       map[string]*dynamodb.AttributeValue
 	Attributes map[string]*dynamodb.AttributeValue
+	Aliases    map[string]*string
 }
 
 // Interpreter dynamodb expression interpreter interface
@@ -48,3 +52,33 @@ type Interpreter interface {
 	Match(input MatchInput) (bool, error)
 	Update(input UpdateInput) error
 }
+
+// errCodeValidation AWS error code of the invalid requests
+const errCodeValidation = "ValidationException"
+
+// AsAWSError classifies the error returned by the interpreter into the AWS error code and message
+// returned by DynamoDB, the invalid expressions and attribute values are validation errors
+func AsAWSError(err error) (code string, message string) {
+	var (
+		awsErr     awserr.Error
+		parseError language.ParseError
+	)
+
+	switch {
+	case err == nil:
+		return "", ""
+	case errors.As(err, &awsErr):
+		return awsErr.Code(), awsErr.Message()
+	case errors.Is(err, ErrSyntaxError):
+		// the evaluation errors are wrapped with their inspected form, e.g. ERROR: type mismatch
+		msg := strings.TrimPrefix(err.Error(), ErrSyntaxError.Error()+": ")
+
+		return errCodeValidation, "Invalid expression: " + strings.TrimPrefix(msg, "ERROR: ")
+	case errors.Is(err, ErrUnsupportedFeature), errors.Is(err, language.ErrEvaluation), errors.Is(err, language.ErrKeyUpdate):
+		return errCodeValidation, err.Error()
+	case errors.As(err, &parseError):
+		return errCodeValidation, "Invalid expression: " + parseError.Error()
+	}
+
+	return dynamodb.ErrCodeInternalServerError, err.Error()
+}
@@ -0,0 +1,81 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/truora/minidyn/interpreter/language"
+)
+
+func TestAsAWSError(t *testing.T) {
+	lang := Language{}
+
+	_, mismatchErr := lang.Match(MatchInput{
+		Expression: "age < :name",
+		Item:       map[string]*dynamodb.AttributeValue{"age": {N: aws.String("1")}},
+		Attributes: map[string]*dynamodb.AttributeValue{":name": {S: aws.String("juan")}},
+	})
+
+	_, undefinedErr := lang.Match(MatchInput{
+		Expression: "age = :age",
+		Item:       map[string]*dynamodb.AttributeValue{"age": {N: aws.String("1")}},
+	})
+
+	tests := []struct {
+		name            string
+		err             error
+		expectedCode    string
+		expectedMessage string
+	}{
+		{
+			name:            "type mismatch",
+			err:             mismatchErr,
+			expectedCode:    "ValidationException",
+			expectedMessage: "Invalid expression: type mismatch: N < S",
+		},
+		{
+			name:            "undefined placeholder",
+			err:             undefinedErr,
+			expectedCode:    "ValidationException",
+			expectedMessage: "Invalid expression: an expression attribute value used in expression is not defined; attribute value: :age",
+		},
+		{
+			name:            "evaluation",
+			err:             fmt.Errorf("%w: the condition must be a boolean, got S", language.ErrEvaluation),
+			expectedCode:    "ValidationException",
+			expectedMessage: "invalid condition evaluation: the condition must be a boolean, got S",
+		},
+		{
+			name:            "aws error",
+			err:             awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil),
+			expectedCode:    dynamodb.ErrCodeConditionalCheckFailedException,
+			expectedMessage: "The conditional request failed",
+		},
+		{
+			name:            "unknown",
+			err:             errors.New("boom"),
+			expectedCode:    dynamodb.ErrCodeInternalServerError,
+			expectedMessage: "boom",
+		},
+		{
+			name: "nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, message := AsAWSError(tt.err)
+			if code != tt.expectedCode {
+				t.Errorf("wrong code. expected=%q, got=%q", tt.expectedCode, code)
+			}
+
+			if message != tt.expectedMessage {
+				t.Errorf("wrong message. expected=%q, got=%q", tt.expectedMessage, message)
+			}
+		})
+	}
+}
@@ -20,26 +20,18 @@ func (li *Language) Match(input MatchInput) (bool, error) {
 	program := p.ParseDynamoExpression()
 	env := language.NewEnvironment()
 
-	if len(p.Errors()) != 0 {
-		return false, fmt.Errorf("%w: %s", ErrSyntaxError, strings.Join(p.Errors(), "\n"))
+	if err := parserError(p); err != nil {
+		return false, err
 	}
 
-	item := map[string]*dynamodb.AttributeValue{}
-
-	alises := map[string]string{}
-	for k, v := range input.Aliases {
-		alises[*v] = k
+	err := validatePlaceholders(program, input.Aliases, input.Attributes)
+	if err != nil {
+		return false, err
 	}
 
-	for field, val := range input.Item {
-		if n, ok := alises[field]; ok {
-			field = n
-		}
-
-		item[field] = val
-	}
+	env.Aliases = expressionAliases(input.Aliases)
 
-	err := env.AddAttributes(item)
+	err = env.AddAttributes(input.Item)
 	if err != nil {
 		return false, fmt.Errorf("%w: %s", ErrUnsupportedFeature, err.Error())
 	}
@@ -64,8 +56,68 @@ func (li *Language) Match(input MatchInput) (bool, error) {
 
 // Update change the item with given expression and attributes
 func (li *Language) Update(input UpdateInput) error {
-	return fmt.Errorf(
-		"%w: language interpreter do not support updates",
-		ErrUnsupportedFeature,
-	)
+	update, err := CompileUpdate(input.Expression)
+	if err != nil {
+		return err
+	}
+
+	item, err := update.Apply(input.Item, input.Aliases, input.Attributes)
+	if err != nil {
+		return err
+	}
+
+	if li.Debug {
+		fmt.Printf("evaluating: %q\nin: %v\n$>%v\n", update, input.Item, item)
+	}
+
+	for field := range input.Item {
+		delete(input.Item, field)
+	}
+
+	for field, val := range item {
+		input.Item[field] = val
+	}
+
+	return nil
+}
+
+// parserError returns the syntax error with the errors found by the parser and their positions
+func parserError(p *language.Parser) error {
+	if len(p.Errors()) == 0 {
+		return nil
+	}
+
+	msgs := []string{}
+	for _, err := range p.ParseErrors() {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Errorf("%w: %s", ErrSyntaxError, strings.Join(msgs, "\n"))
+}
+
+// validatePlaceholders fails when the expression uses a name or value placeholder not defined in the maps
+func validatePlaceholders(n language.Node, names map[string]*string, values map[string]*dynamodb.AttributeValue) error {
+	placeholder, ok := language.UndefinedPlaceholder(n, names, values)
+	if !ok {
+		return nil
+	}
+
+	if strings.HasPrefix(placeholder, "#") {
+		return fmt.Errorf("%w: an expression attribute name used in the document path is not defined; attribute name: %s", ErrSyntaxError, placeholder)
+	}
+
+	return fmt.Errorf("%w: an expression attribute value used in expression is not defined; attribute value: %s", ErrSyntaxError, placeholder)
+}
+
+// expressionAliases maps the expression attribute names to the attributes names
+func expressionAliases(names map[string]*string) map[string]string {
+	aliases := make(map[string]string, len(names))
+
+	for alias, name := range names {
+		if name != nil {
+			aliases[alias] = *name
+		}
+	}
+
+	return aliases
 }
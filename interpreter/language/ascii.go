@@ -0,0 +1,74 @@
+package language
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// lookalikes Unicode characters commonly pasted instead of the ASCII ones
+var lookalikes = map[rune]string{
+	'\u2212': "-",  // minus sign
+	'\u2013': "-",  // en dash
+	'\u2014': "-",  // em dash
+	'\u00a0': " ",  // no-break space
+	'\u2009': " ",  // thin space
+	'\u3000': " ",  // ideographic space
+	'\u2264': "<=", // less-than or equal to
+	'\u2265': ">=", // greater-than or equal to
+	'\u2260': "<>", // not equal to
+	'\uff08': "(",  // fullwidth left parenthesis
+	'\uff09': ")",  // fullwidth right parenthesis
+	'\uff0c': ",",  // fullwidth comma
+}
+
+// ValidateASCII fails at the first character that is not printable ASCII outside the string
+// literals, tabs and new lines are allowed. The expressions only use ASCII, the attribute values
+// with other characters must be given as expression attribute values or string literals
+func ValidateASCII(input string) error {
+	line, lineStart := 1, 0
+	inString, escaped := false, false
+
+	for offset, r := range input {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && r == '\\':
+			escaped = true
+		case r == '"':
+			inString = !inString
+		}
+
+		if r == '\n' {
+			line, lineStart = line+1, offset+1
+		}
+
+		if inString || isPrintableASCII(r) {
+			continue
+		}
+
+		return ParseError{
+			Position: Position{Offset: offset, Line: line, Column: offset - lineStart + 1},
+			Message:  invalidCharacterMessage(r),
+		}
+	}
+
+	return nil
+}
+
+func isPrintableASCII(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' || (r >= ' ' && r <= '~')
+}
+
+func invalidCharacterMessage(r rune) string {
+	if r == utf8.RuneError {
+		return "invalid UTF-8 encoding"
+	}
+
+	msg := fmt.Sprintf("invalid character %U %q", r, r)
+
+	if replacement, ok := lookalikes[r]; ok {
+		return fmt.Sprintf("%s, did you mean '%s'?", msg, replacement)
+	}
+
+	return msg + ", the expressions only support ASCII characters"
+}
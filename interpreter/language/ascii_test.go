@@ -0,0 +1,36 @@
+package language
+
+import "testing"
+
+func TestValidateASCII(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a = :v AND\n\tb <> :w", ""},
+		{`a = "café \"−\"" AND b = :v`, ""},
+		{`a = "x\\" AND b ≤ :v`, "line 1, column 17: invalid character U+2264 '≤', did you mean '<='?"},
+		{"SET a = a − :n", "line 1, column 11: invalid character U+2212 '−', did you mean '-'?"},
+		{"a = :v", "line 1, column 4: invalid character U+00A0 '\\u00a0', did you mean ' '?"},
+		{"a = :v\nAND café = :w", "line 2, column 8: invalid character U+00E9 'é', the expressions only support ASCII characters"},
+		{"a ≤ :v", "line 1, column 3: invalid character U+2264 '≤', did you mean '<='?"},
+		{"a = :v\x00", "line 1, column 7: invalid character U+0000 '\\x00', the expressions only support ASCII characters"},
+		{"a = \xff", "line 1, column 5: invalid UTF-8 encoding"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateASCII(tt.input)
+
+		if tt.expected == "" {
+			if err != nil {
+				t.Errorf("unexpected error for %q: %v", tt.input, err)
+			}
+
+			continue
+		}
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package language
 
 import (
 	"bytes"
+	"strconv"
 	"strings"
 )
 
@@ -41,9 +42,9 @@ type Expression interface {
 	expressionNode()
 }
 
-// Identifier identifier expression node
+// Identifier identifier expression node, the placeholders keep the prefix in the value, e.g. :v
 type Identifier struct {
-	Token Token // the token.IDENT token
+	Token Token // the token.IDENT, token.ValuePlaceholder or token.NamePlaceholder token
 	Value string
 }
 
@@ -56,6 +57,52 @@ func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 
 func (i *Identifier) String() string { return i.Value }
 
+// BoolLiteral boolean literal operand, e.g. true
+type BoolLiteral struct {
+	Token Token // the token.BOOL token
+	Value bool
+}
+
+func (b *BoolLiteral) expressionNode() {
+	_ = 1 // HACK for passing coverage
+}
+
+// TokenLiteral returns the literal token of the node
+func (b *BoolLiteral) TokenLiteral() string { return b.Token.Literal }
+
+func (b *BoolLiteral) String() string { return b.Token.Literal }
+
+// NumberLiteral number literal operand, e.g. 21 or 1.5
+type NumberLiteral struct {
+	Token Token
+	Value float64
+}
+
+func (n *NumberLiteral) expressionNode() {
+	_ = 1 // HACK for passing coverage
+}
+
+// TokenLiteral returns the literal token of the node
+func (n *NumberLiteral) TokenLiteral() string { return n.Token.Literal }
+
+func (n *NumberLiteral) String() string { return n.Token.Literal }
+
+// StringLiteral string literal operand, e.g. "ACTIVE"
+type StringLiteral struct {
+	Token Token
+	// Value is the string without the quotes and escapes
+	Value string
+}
+
+func (s *StringLiteral) expressionNode() {
+	_ = 1 // HACK for passing coverage
+}
+
+// TokenLiteral returns the literal token of the node
+func (s *StringLiteral) TokenLiteral() string { return s.Token.Literal }
+
+func (s *StringLiteral) String() string { return s.Token.Literal }
+
 // ExpressionStatement is the expression node
 type ExpressionStatement struct {
 	Token      Token // the return token
@@ -98,6 +145,12 @@ func (pe *PrefixExpression) String() string {
 
 	out.WriteString("(")
 	out.WriteString(pe.Operator)
+
+	// the keyword operators are separated from the operand, e.g. (NOT a) and (-a)
+	if pe.Operator == NOT {
+		out.WriteString(" ")
+	}
+
 	out.WriteString(pe.Right.String())
 	out.WriteString(")")
 
@@ -186,11 +239,248 @@ func (ce *BetweenExpression) TokenLiteral() string {
 func (ce *BetweenExpression) String() string {
 	var out bytes.Buffer
 
+	out.WriteString("(")
 	out.WriteString(ce.Left.String())
 	out.WriteString(" BETWEEN ")
 	out.WriteString(ce.Range[0].String())
 	out.WriteString(" AND ")
 	out.WriteString(ce.Range[1].String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// UpdateExpression the root node of the update expressions AST
+type UpdateExpression struct {
+	Statement *UpdateStatement
+}
+
+func (ue *UpdateExpression) String() string {
+	if ue.Statement == nil {
+		return ""
+	}
+
+	return ue.Statement.String()
+}
+
+// TokenLiteral returns the literal token of the node
+func (ue *UpdateExpression) TokenLiteral() string {
+	if ue.Statement == nil {
+		return ""
+	}
+
+	return ue.Statement.TokenLiteral()
+}
+
+// UpdateStatement groups the clauses of an update expression
+type UpdateStatement struct {
+	Token  Token // the first clause token
+	Set    *UpdateClause
+	Remove *UpdateClause
+	Add    *UpdateClause
+	Delete *UpdateClause
+}
+
+func (us *UpdateStatement) statementNode() {
+	_ = 1 // HACK for passing coverage
+}
+
+// TokenLiteral returns the literal token of the node
+func (us *UpdateStatement) TokenLiteral() string { return us.Token.Literal }
+
+// Clauses returns the present clauses in the canonical order SET, REMOVE, ADD, DELETE
+func (us *UpdateStatement) Clauses() []*UpdateClause {
+	clauses := make([]*UpdateClause, 0, 4)
+
+	for _, c := range []*UpdateClause{us.Set, us.Remove, us.Add, us.Delete} {
+		if c != nil {
+			clauses = append(clauses, c)
+		}
+	}
+
+	return clauses
+}
+
+func (us *UpdateStatement) String() string {
+	clauses := []string{}
+	for _, c := range us.Clauses() {
+		clauses = append(clauses, c.String())
+	}
+
+	return strings.Join(clauses, " ")
+}
+
+// UpdateClause groups the actions of an update keyword, e.g. SET a = :a, b = :b
+type UpdateClause struct {
+	Token   Token // the SET, REMOVE, ADD or DELETE token
+	Actions []*UpdateAction
+}
+
+// TokenLiteral returns the literal token of the node
+func (uc *UpdateClause) TokenLiteral() string { return uc.Token.Literal }
+
+func (uc *UpdateClause) String() string {
+	var out bytes.Buffer
+
+	actions := []string{}
+	for _, a := range uc.Actions {
+		actions = append(actions, a.String())
+	}
+
+	out.WriteString(string(uc.Token.Type))
+	out.WriteString(" ")
+	out.WriteString(strings.Join(actions, ", "))
+
+	return out.String()
+}
+
+// UpdateAction is a single action of an update clause
+type UpdateAction struct {
+	Token Token // the SET, REMOVE, ADD or DELETE token of the clause
+	Path  Expression
+	// Value is nil for the REMOVE actions
+	Value Expression
+}
+
+// TokenLiteral returns the literal token of the node
+func (ua *UpdateAction) TokenLiteral() string { return ua.Token.Literal }
+
+func (ua *UpdateAction) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ua.Path.String())
+
+	switch ua.Token.Type {
+	case SET:
+		out.WriteString(" = ")
+		out.WriteString(ua.Value.String())
+	case ADD, DELETE:
+		out.WriteString(" ")
+		out.WriteString(ua.Value.String())
+	}
+
+	return out.String()
+}
+
+// IndexExpression document path element access expression, e.g. a[0] or a.b
+type IndexExpression struct {
+	Token Token // The '[' or '.' token
+	Left  Expression
+	// Index is the list position accessed with '['
+	Index int
+	// Key is the map key accessed with '.'
+	Key *Identifier
+}
+
+func (ie *IndexExpression) expressionNode() {
+	_ = 1 // HACK for passing coverage
+}
+
+// TokenLiteral returns the literal token of the node
+func (ie *IndexExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ie.Left.String())
+
+	if ie.Token.Type == DOT {
+		out.WriteString(".")
+		out.WriteString(ie.Key.String())
+
+		return out.String()
+	}
+
+	out.WriteString("[")
+	out.WriteString(strconv.Itoa(ie.Index))
+	out.WriteString("]")
 
 	return out.String()
 }
+
+// sharedExpression condition repeated in the expression, added by EliminateCommonSubexpressions.
+// The result is evaluated once and kept in the environment
+type sharedExpression struct {
+	Expression Expression
+}
+
+func (se *sharedExpression) expressionNode() {
+	_ = 1 // HACK for passing coverage
+}
+
+// TokenLiteral returns the literal token of the node
+func (se *sharedExpression) TokenLiteral() string { return se.Expression.TokenLiteral() }
+
+func (se *sharedExpression) String() string { return se.Expression.String() }
+
+// attributeAccessor top level attribute with the name already resolved, added by
+// PrecomputeAccessors. It is evaluated with a single lookup in the environment
+type attributeAccessor struct {
+	Identifier *Identifier
+	key        string
+}
+
+func (aa *attributeAccessor) expressionNode() {
+	_ = 1 // HACK for passing coverage
+}
+
+// TokenLiteral returns the literal token of the node
+func (aa *attributeAccessor) TokenLiteral() string { return aa.Identifier.TokenLiteral() }
+
+func (aa *attributeAccessor) String() string { return aa.Identifier.String() }
+
+// InExpression membership expression, e.g. a IN (:x, :y)
+type InExpression struct {
+	Token      Token // The 'IN' token
+	Left       Expression
+	Candidates []Expression
+	// values hash set of the candidates precomputed by PrecomputeIn, nil when unknown
+	values map[string]bool
+}
+
+func (ie *InExpression) expressionNode() {
+	_ = 1 // HACK for passing coverage
+}
+
+// TokenLiteral returns the literal token of the node
+func (ie *InExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *InExpression) String() string {
+	var out bytes.Buffer
+
+	candidates := []string{}
+	for _, c := range ie.Candidates {
+		candidates = append(candidates, c.String())
+	}
+
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString(" IN (")
+	out.WriteString(strings.Join(candidates, ", "))
+	out.WriteString("))")
+
+	return out.String()
+}
+
+// ProjectionExpression the root node of the projection expressions AST, e.g. id, profile.name
+type ProjectionExpression struct {
+	Token Token // the first token of the expression
+	// Paths are Identifier or IndexExpression nodes
+	Paths []Expression
+}
+
+// TokenLiteral returns the literal token of the node
+func (pe *ProjectionExpression) TokenLiteral() string { return pe.Token.Literal }
+
+func (pe *ProjectionExpression) String() string {
+	paths := []string{}
+	for _, p := range pe.Paths {
+		paths = append(paths, p.String())
+	}
+
+	return strings.Join(paths, ", ")
+}
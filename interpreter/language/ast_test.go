@@ -135,8 +135,8 @@ func TestBetweenExpression(t *testing.T) {
 		t.Fatalf("wrong token literal. expected=%q, got=%q", "NOT", tl)
 	}
 
-	if be.String() != "b BETWEEN a AND b" {
-		t.Fatalf("wrong string representation. expected=%q, got=%q", "b BETWEEN a AND b", be.String())
+	if be.String() != "(b BETWEEN a AND b)" {
+		t.Fatalf("wrong string representation. expected=%q, got=%q", "(b BETWEEN a AND b)", be.String())
 	}
 
 	be.expressionNode()
@@ -163,3 +163,29 @@ func BenchmarkCallExpression(b *testing.B) {
 		}
 	}
 }
+
+func TestStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a = :x AND NOT (b < :y)", "((a = :x) AND (NOT (b < :y)))"},
+		{"NOT a", "(NOT a)"},
+		{"NOT NOT a BETWEEN :x AND :y", "(NOT (NOT (a BETWEEN :x AND :y)))"},
+		{"a BETWEEN :x AND :y AND b IN (:z)", "((a BETWEEN :x AND :y) AND (b IN (:z)))"},
+		{"a OR b AND c OR d", "((a OR (b AND c)) OR d)"},
+		{"begins_with(a.b[0], :p) OR size(#c) >= :n", "(begins_with(a.b[0], :p) OR (size(#c) >= :n))"},
+	}
+
+	for _, tt := range tests {
+		actual := testParse(t, tt.input).String()
+		if actual != tt.expected {
+			t.Errorf("wrong string representation of %q. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+
+		reparsed := testParse(t, actual).String()
+		if reparsed != actual {
+			t.Errorf("the string representation of %q does not round-trip. expected=%q, got=%q", tt.input, actual, reparsed)
+		}
+	}
+}
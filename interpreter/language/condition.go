@@ -0,0 +1,121 @@
+package language
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ErrEvaluation when a condition can not be evaluated against the item, e.g. a < :b with booleans
+var ErrEvaluation = errors.New("invalid condition evaluation")
+
+// ErrTimeout when the evaluation of the condition exceeds the time limit
+var ErrTimeout = errors.New("the evaluation exceeded the time limit")
+
+// EvalCondition evaluates the condition or filter expression against the item, the names and
+// values are the expression attribute names and values. The comparators between different types
+// and the attributes missing in the item are false, as in DynamoDB
+func EvalCondition(expr *DynamoExpression, item map[string]*dynamodb.AttributeValue, names map[string]*string, values map[string]*dynamodb.AttributeValue) (bool, error) {
+	return evalCondition(expr, item, values, nameAliases(names), 0)
+}
+
+// EvalWithTimeout works like EvalCondition but fails with ErrTimeout when the evaluation takes
+// longer than d, it is meant as a safety net for huge expressions or items
+func EvalWithTimeout(expr *DynamoExpression, item map[string]*dynamodb.AttributeValue, names map[string]*string, values map[string]*dynamodb.AttributeValue, d time.Duration) (bool, error) {
+	return evalCondition(expr, item, values, nameAliases(names), d)
+}
+
+// ConjunctResult is the result of a top level AND term of a condition
+type ConjunctResult struct {
+	// Source is the term of the condition, e.g. (a = :x)
+	Source  string
+	Matched bool
+	// Err is set when the term can not be evaluated against the item
+	Err error
+}
+
+// EvalConjuncts evaluates each top level AND term of the condition against the item, it tells
+// which terms excluded the item when the condition does not match
+func EvalConjuncts(expr *DynamoExpression, item map[string]*dynamodb.AttributeValue, names map[string]*string, values map[string]*dynamodb.AttributeValue) []ConjunctResult {
+	results := []ConjunctResult{}
+
+	var terms []Expression
+	if expr != nil {
+		if stmt, ok := expr.Statement.(*ExpressionStatement); ok && stmt != nil {
+			terms = Conjuncts(stmt.Expression)
+		}
+	}
+
+	env, err := newConditionEnvironment(item, values, nameAliases(names))
+
+	for _, term := range terms {
+		r := ConjunctResult{Source: term.String(), Err: err}
+
+		if err == nil {
+			r.Matched, r.Err = conditionResult(Eval(term, env), env, 0)
+		}
+
+		results = append(results, r)
+	}
+
+	return results
+}
+
+// evalCondition the timeout is disabled when d is zero
+func evalCondition(expr *DynamoExpression, item, values map[string]*dynamodb.AttributeValue, names map[string]string, d time.Duration) (bool, error) {
+	env, err := newConditionEnvironment(item, values, names)
+	if err != nil {
+		return false, err
+	}
+
+	if d != 0 {
+		env.deadline = time.Now().Add(d)
+	}
+
+	return conditionResult(Eval(expr, env), env, d)
+}
+
+// nameAliases converts the expression attribute names of the SDK into the environment aliases
+func nameAliases(names map[string]*string) map[string]string {
+	aliases := make(map[string]string, len(names))
+
+	for alias, name := range names {
+		if name != nil {
+			aliases[alias] = *name
+		}
+	}
+
+	return aliases
+}
+
+func newConditionEnvironment(item, values map[string]*dynamodb.AttributeValue, names map[string]string) (*Environment, error) {
+	env := NewEnvironment()
+	env.Aliases = names
+	env.Lenient = true
+
+	for _, attributes := range []map[string]*dynamodb.AttributeValue{item, values} {
+		if err := env.AddAttributes(attributes); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrEvaluation, err.Error())
+		}
+	}
+
+	return env, nil
+}
+
+func conditionResult(result Object, env *Environment, d time.Duration) (bool, error) {
+	if env.timedOut {
+		return false, fmt.Errorf("%w: %s", ErrTimeout, d)
+	}
+
+	if errObj, ok := result.(*Error); ok {
+		return false, fmt.Errorf("%w: %s", ErrEvaluation, errObj.Message)
+	}
+
+	if result.Type() != ObjectTypeBoolean {
+		return false, fmt.Errorf("%w: the condition must be a boolean, got %s", ErrEvaluation, result.Type())
+	}
+
+	return result == TRUE, nil
+}
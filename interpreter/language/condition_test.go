@@ -0,0 +1,235 @@
+package language
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestEvalCondition(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"name":    {S: aws.String("john")},
+		"age":     {N: aws.String("30")},
+		"active":  {BOOL: aws.Bool(true)},
+		"profile": {M: map[string]*dynamodb.AttributeValue{"city": {S: aws.String("bogota")}}},
+	}
+
+	values := map[string]*dynamodb.AttributeValue{
+		":name": {S: aws.String("john")},
+		":min":  {N: aws.String("18")},
+		":max":  {N: aws.String("40")},
+		":city": {S: aws.String("bog")},
+		":txt":  {S: aws.String("30")},
+		":yes":  {BOOL: aws.Bool(true)},
+	}
+
+	names := map[string]*string{"#p": aws.String("profile")}
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"name = :name", true},
+		{"name <> :name", false},
+		{"age BETWEEN :min AND :max AND begins_with(#p.city, :city)", true},
+		{"NOT age < :min", true},
+		{"age = :txt", false},
+		{"age <> :txt", true},
+		{"age < :txt", false},
+		{"age >= :txt", false},
+		{"missing = :name", false},
+		{"missing < :max", false},
+		{"#p.missing >= :min OR active = :yes", true},
+	}
+
+	for _, tt := range tests {
+		actual, err := EvalCondition(testParse(t, tt.input), item, names, values)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.input, err)
+		}
+
+		if actual != tt.expected {
+			t.Errorf("wrong result for %q. expected=%v, got=%v", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestEvalConditionErrors(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"active": {BOOL: aws.Bool(true)},
+		"name":   {S: aws.String("john")},
+	}
+
+	values := map[string]*dynamodb.AttributeValue{
+		":yes": {BOOL: aws.Bool(false)},
+	}
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"active < :yes", "invalid condition evaluation: unknown operator: BOOL < BOOL"},
+		{"name", "invalid condition evaluation: the condition must be a boolean, got S"},
+	}
+
+	for _, tt := range tests {
+		_, err := EvalCondition(testParse(t, tt.input), item, nil, values)
+		if !errors.Is(err, ErrEvaluation) {
+			t.Fatalf("expected an evaluation error for %q. got=%v", tt.input, err)
+		}
+
+		if err.Error() != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, err.Error())
+		}
+	}
+}
+
+func TestEvalConditionInvalidUTF8(t *testing.T) {
+	invalid := "caf\xe9"
+
+	tests := []struct {
+		item   map[string]*dynamodb.AttributeValue
+		values map[string]*dynamodb.AttributeValue
+	}{
+		{
+			map[string]*dynamodb.AttributeValue{"name": {S: aws.String("café")}},
+			map[string]*dynamodb.AttributeValue{":name": {S: aws.String(invalid)}},
+		},
+		{
+			map[string]*dynamodb.AttributeValue{"name": {S: aws.String(invalid)}},
+			map[string]*dynamodb.AttributeValue{":name": {S: aws.String("café")}},
+		},
+		{
+			map[string]*dynamodb.AttributeValue{"name": {L: []*dynamodb.AttributeValue{{S: aws.String(invalid)}}}},
+			map[string]*dynamodb.AttributeValue{":name": {S: aws.String("café")}},
+		},
+		{
+			map[string]*dynamodb.AttributeValue{"name": {S: aws.String("café")}},
+			map[string]*dynamodb.AttributeValue{":name": {SS: []*string{aws.String("a"), aws.String(invalid)}}},
+		},
+	}
+
+	for i, tt := range tests {
+		_, err := EvalCondition(testParse(t, "name = :name"), tt.item, nil, tt.values)
+		if !errors.Is(err, ErrEvaluation) || !strings.Contains(err.Error(), ErrInvalidUTF8.Error()+`: "caf\xe9"`) {
+			t.Errorf("tests[%d] - expected an invalid UTF-8 error. got=%v", i, err)
+		}
+	}
+
+	// the multi-byte characters are valid, e.g. é
+	matched, err := EvalCondition(testParse(t, "name = :name"),
+		map[string]*dynamodb.AttributeValue{"name": {S: aws.String("café")}}, nil,
+		map[string]*dynamodb.AttributeValue{":name": {S: aws.String("caf\u00e9")}})
+	if err != nil || !matched {
+		t.Errorf("expected the valid UTF-8 strings to match. got=%v, %v", matched, err)
+	}
+}
+
+func TestEvalWithTimeout(t *testing.T) {
+	terms := make([]string, 5000)
+	for i := range terms {
+		terms[i] = "contains(tags, :tag)"
+	}
+
+	expr := testParse(t, strings.Join(terms, " OR ")+" OR a = :a")
+
+	item := map[string]*dynamodb.AttributeValue{
+		"a":    {N: aws.String("1")},
+		"tags": {SS: []*string{aws.String("x"), aws.String("y")}},
+	}
+
+	values := map[string]*dynamodb.AttributeValue{
+		":a":   {N: aws.String("1")},
+		":tag": {S: aws.String("z")},
+	}
+
+	_, err := EvalWithTimeout(expr, item, nil, values, time.Nanosecond)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected a timeout error. got=%v", err)
+	}
+
+	actual, err := EvalWithTimeout(expr, item, nil, values, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !actual {
+		t.Errorf("wrong result for the expression evaluated in time. expected=true, got=false")
+	}
+}
+
+func TestEvalConjuncts(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"a":      {S: aws.String("x")},
+		"b":      {N: aws.String("3")},
+		"active": {BOOL: aws.Bool(true)},
+	}
+
+	values := map[string]*dynamodb.AttributeValue{
+		":x": {S: aws.String("x")},
+		":y": {N: aws.String("5")},
+	}
+
+	tests := []struct {
+		input    string
+		expected []ConjunctResult
+	}{
+		{
+			"a = :x AND b > :y",
+			[]ConjunctResult{
+				{Source: "(a = :x)", Matched: true},
+				{Source: "(b > :y)", Matched: false},
+			},
+		},
+		{
+			"a = :x AND (b > :y OR b < :y) AND NOT active < :y",
+			[]ConjunctResult{
+				{Source: "(a = :x)", Matched: true},
+				{Source: "((b > :y) OR (b < :y))", Matched: true},
+				{Source: "(NOT (active < :y))", Matched: true},
+			},
+		},
+		{
+			"b < :y",
+			[]ConjunctResult{
+				{Source: "(b < :y)", Matched: true},
+			},
+		},
+		{
+			"#b < :y AND #a <> :x",
+			[]ConjunctResult{
+				{Source: "(#b < :y)", Matched: true},
+				{Source: "(#a <> :x)", Matched: false},
+			},
+		},
+	}
+
+	names := map[string]*string{"#a": aws.String("a"), "#b": aws.String("b")}
+
+	for _, tt := range tests {
+		actual := EvalConjuncts(testParse(t, tt.input), item, names, values)
+
+		if len(actual) != len(tt.expected) {
+			t.Fatalf("wrong number of conjuncts for %q. expected=%d, got=%d", tt.input, len(tt.expected), len(actual))
+		}
+
+		for i, r := range actual {
+			if r.Err != nil {
+				t.Fatalf("unexpected error for %q: %v", r.Source, r.Err)
+			}
+
+			if r != tt.expected[i] {
+				t.Errorf("wrong result for the term %d of %q. expected=%+v, got=%+v", i, tt.input, tt.expected[i], r)
+			}
+		}
+	}
+
+	actual := EvalConjuncts(testParse(t, "a = :x AND active < active"), item, nil, values)
+	if len(actual) != 2 || !errors.Is(actual[1].Err, ErrEvaluation) || actual[0].Err != nil {
+		t.Errorf("expected an evaluation error only for the second term. got=%+v", actual)
+	}
+}
@@ -0,0 +1,201 @@
+package language
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Rough evaluation cost of the expression nodes, the values are relative to each other
+const (
+	costLookup     = 1
+	costLogical    = 1
+	costComparison = 2
+	// equality may need a deep comparison of documents and sets
+	costEquality = 4
+	costBetween  = 4
+	// functionCostDefault used for the functions without a specific cost
+	functionCostDefault = 8
+)
+
+var functionCosts = map[string]int{
+	"attribute_exists":     6,
+	"attribute_not_exists": 6,
+	"attribute_type":       6,
+	"begins_with":          8,
+	"size":                 8,
+	// contains may scan the whole collection
+	"contains": 12,
+}
+
+// CostEstimate returns a rough estimation of the cost of evaluating the expression,
+// function calls and equality comparisons are more expensive than scalar comparisons
+func CostEstimate(n Node) int {
+	switch node := n.(type) {
+	case *DynamoExpression:
+		return CostEstimate(node.Statement)
+	case *ExpressionStatement:
+		return CostEstimate(node.Expression)
+	case *Identifier:
+		return costLookup
+	case *IndexExpression:
+		return costLookup + CostEstimate(node.Left)
+	case *PrefixExpression:
+		return costLogical + CostEstimate(node.Right)
+	case *InfixExpression:
+		return infixCost(node.Operator) + CostEstimate(node.Left) + CostEstimate(node.Right)
+	case *BetweenExpression:
+		return costBetween + CostEstimate(node.Left) + CostEstimate(node.Range[0]) + CostEstimate(node.Range[1])
+	case *InExpression:
+		cost := CostEstimate(node.Left)
+		for _, c := range node.Candidates {
+			cost += costEquality + CostEstimate(c)
+		}
+
+		return cost
+	case *CallExpression:
+		return callCost(node)
+	}
+
+	return 0
+}
+
+func infixCost(operator string) int {
+	switch operator {
+	case AND, OR:
+		return costLogical
+	case EQ, NotEQ:
+		return costEquality
+	}
+
+	return costComparison
+}
+
+func callCost(node *CallExpression) int {
+	cost := functionCostDefault
+
+	if identifier, ok := node.Function.(*Identifier); ok {
+		if c, ok := functionCosts[identifier.Value]; ok {
+			cost = c
+		}
+	}
+
+	for _, arg := range node.Arguments {
+		cost += CostEstimate(arg)
+	}
+
+	return cost
+}
+
+// Rough fraction of the items matching a condition, lower values are more selective
+const (
+	selectivityEquality   = 0.1
+	selectivityComparison = 0.33
+	selectivityBetween    = 0.25
+	// selectivityDefault used for the conditions without a specific estimation
+	selectivityDefault = 0.5
+)
+
+var functionSelectivities = map[string]float64{
+	"attribute_exists":     0.9,
+	"attribute_not_exists": 0.5,
+	"attribute_type":       0.5,
+	"begins_with":          0.2,
+	"contains":             0.3,
+}
+
+// Selectivity returns a rough estimation in [0, 1] of the fraction of items matching the
+// condition, equality comparisons are highly selective and attribute_exists is not
+func Selectivity(n Node) float64 {
+	switch node := n.(type) {
+	case *DynamoExpression:
+		return Selectivity(node.Statement)
+	case *ExpressionStatement:
+		return Selectivity(node.Expression)
+	case *PrefixExpression:
+		return 1 - Selectivity(node.Right)
+	case *InfixExpression:
+		return infixSelectivity(node)
+	case *BetweenExpression:
+		return selectivityBetween
+	case *InExpression:
+		return math.Min(1, selectivityEquality*float64(len(node.Candidates)))
+	case *CallExpression:
+		if identifier, ok := node.Function.(*Identifier); ok {
+			if s, ok := functionSelectivities[identifier.Value]; ok {
+				return s
+			}
+		}
+	}
+
+	return selectivityDefault
+}
+
+func infixSelectivity(node *InfixExpression) float64 {
+	switch node.Operator {
+	case AND:
+		return Selectivity(node.Left) * Selectivity(node.Right)
+	case OR:
+		left, right := Selectivity(node.Left), Selectivity(node.Right)
+
+		return left + right - left*right
+	case EQ:
+		return selectivityEquality
+	case NotEQ:
+		return 1 - selectivityEquality
+	}
+
+	return selectivityComparison
+}
+
+// ErrComplexity when the expression exceeds the complexity budget
+var ErrComplexity = errors.New("the expression is too complex")
+
+// ComplexityScore returns the sum of the nesting depth, the number of operators and the number of
+// function calls of the expression, e.g. 3 for a = :a and 8 for a = :a AND size(b) > :n
+func ComplexityScore(n Node) int {
+	operators, calls := 0, 0
+
+	Walk(n, func(node Node) bool {
+		switch node.(type) {
+		case *PrefixExpression, *InfixExpression, *BetweenExpression, *InExpression:
+			operators++
+		case *CallExpression:
+			calls++
+		}
+
+		return true
+	})
+
+	return expressionDepth(n) + operators + calls
+}
+
+// CheckComplexity fails with ErrComplexity when the complexity score of the expression exceeds max
+func CheckComplexity(n Node, max int) error {
+	if score := ComplexityScore(n); score > max {
+		return fmt.Errorf("%w: the complexity score %d exceeds the maximum %d", ErrComplexity, score, max)
+	}
+
+	return nil
+}
+
+// expressionDepth the number of levels of the expression nodes, the statements are not counted
+func expressionDepth(n Node) int {
+	if isNilNode(n) {
+		return 0
+	}
+
+	depth := 0
+	for _, child := range children(n) {
+		if d := expressionDepth(child); d > depth {
+			depth = d
+		}
+	}
+
+	switch n.(type) {
+	case *DynamoExpression, *ExpressionStatement:
+		return depth
+	}
+
+	return depth + 1
+}
@@ -0,0 +1,164 @@
+package language
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCostEstimate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"a", 1},
+		{"a < :b", 4},
+		{"a = :b", 6},
+		{"NOT a = :b", 7},
+		{"a BETWEEN :x AND :y", 7},
+		{"a < :b AND c > :d", 9},
+		{"attribute_exists(a)", 7},
+		{"contains(a, :b)", 14},
+		{"size(a[0]) > :b", 13},
+	}
+
+	for _, tt := range tests {
+		program := testParse(t, tt.input)
+
+		actual := CostEstimate(program)
+		if actual != tt.expected {
+			t.Errorf("wrong cost for %q. expected=%d, got=%d", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestCostEstimateFunctionsAreMoreExpensive(t *testing.T) {
+	tests := []struct {
+		cheap     string
+		expensive string
+	}{
+		{"a < :b", "begins_with(a, :b)"},
+		{"a < :b AND c < :d", "contains(a, :b) AND c < :d"},
+		{"a = :b", "size(a) = :b"},
+		{"a > :b", "a = :b"},
+	}
+
+	for _, tt := range tests {
+		cheap := CostEstimate(testParse(t, tt.cheap))
+		expensive := CostEstimate(testParse(t, tt.expensive))
+
+		if cheap >= expensive {
+			t.Errorf("expected %q(%d) to be cheaper than %q(%d)", tt.cheap, cheap, tt.expensive, expensive)
+		}
+	}
+}
+
+func testParse(t *testing.T, input string) *DynamoExpression {
+	l := NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseDynamoExpression()
+	checkParserErrors(t, p)
+
+	return program
+}
+
+func TestSelectivity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"a = :x", 0.1},
+		{"a <> :x", 0.9},
+		{"NOT a = :x", 0.9},
+		{"a < :x", 0.33},
+		{"a BETWEEN :x AND :y", 0.25},
+		{"a IN (:x, :y, :z)", 0.3},
+		{"attribute_exists(a)", 0.9},
+		{"begins_with(a, :x)", 0.2},
+		{"a = :x AND b = :y", 0.01},
+		{"a = :x OR b = :y", 0.19},
+	}
+
+	for _, tt := range tests {
+		actual := Selectivity(testParse(t, tt.input))
+		if math.Abs(actual-tt.expected) > 1e-9 {
+			t.Errorf("wrong selectivity for %q. expected=%f, got=%f", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestSelectivityEqualityIsMoreSelective(t *testing.T) {
+	tests := []struct {
+		selective string
+		broad     string
+	}{
+		{"a = :x", "attribute_exists(a)"},
+		{"a = :x AND b = :y", "attribute_exists(a) AND attribute_exists(b)"},
+		{"a = :x AND attribute_exists(b)", "attribute_exists(a) AND attribute_exists(b)"},
+		{"a = :x OR b = :y", "attribute_exists(a) OR attribute_exists(b)"},
+		{"a IN (:x, :y)", "attribute_exists(a)"},
+	}
+
+	for _, tt := range tests {
+		selective := Selectivity(testParse(t, tt.selective))
+		broad := Selectivity(testParse(t, tt.broad))
+
+		if selective >= broad {
+			t.Errorf("expected %q(%f) to be more selective than %q(%f)", tt.selective, selective, tt.broad, broad)
+		}
+	}
+
+	for _, input := range []string{"NOT (a = :x OR b = :y)", "a IN (:a, :b, :c, :d, :e, :f, :g, :h, :i, :j, :k, :l)", "size(a) > :n"} {
+		s := Selectivity(testParse(t, input))
+		if s < 0 || s > 1 {
+			t.Errorf("selectivity of %q out of range. got=%f", input, s)
+		}
+	}
+}
+
+func TestComplexityScore(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"a", 1},
+		{"a = :a", 3},
+		{"NOT a = :a", 5},
+		{"a = :a AND size(b) > :n", 8},
+		{"a BETWEEN :x AND :y", 3},
+		{"a IN (:x, :y, :z)", 3},
+		{"c.d[0] = :v", 5},
+	}
+
+	for _, tt := range tests {
+		actual := ComplexityScore(testParse(t, tt.input))
+		if actual != tt.expected {
+			t.Errorf("wrong complexity for %q. expected=%d, got=%d", tt.input, tt.expected, actual)
+		}
+	}
+
+	simple := ComplexityScore(testParse(t, "a = :a AND b = :b"))
+	complex := ComplexityScore(testParse(t, "(a = :a OR NOT (b = :b AND contains(c, :c))) AND size(d) BETWEEN :x AND :y"))
+
+	if simple >= complex {
+		t.Errorf("expected the simple expression to have a lower score. simple=%d, complex=%d", simple, complex)
+	}
+}
+
+func TestCheckComplexity(t *testing.T) {
+	program := testParse(t, "a = :a AND size(b) > :n")
+
+	if err := CheckComplexity(program, 8); err != nil {
+		t.Errorf("unexpected error at the threshold: %v", err)
+	}
+
+	err := CheckComplexity(program, 7)
+	if !errors.Is(err, ErrComplexity) {
+		t.Fatalf("expected a complexity error. got=%v", err)
+	}
+
+	expected := "the expression is too complex: the complexity score 8 exceeds the maximum 7"
+	if err.Error() != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, err.Error())
+	}
+}
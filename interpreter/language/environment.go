@@ -3,13 +3,77 @@ package language
 import (
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
+// CompareHook overrides the comparison of two values, it returns the result of the
+// comparison (-1, 0 or 1) and whether the values were handled by the hook
+type CompareHook func(op string, left, right *dynamodb.AttributeValue) (int, bool, error)
+
+// EqualityOptions overrides the equality of the values by attribute type, it is used by the
+// =, <> and IN comparators including the values nested in maps and lists
+type EqualityOptions struct {
+	// Equal compares two values of the attribute type, the numbers keep the representation
+	// they were provided with, e.g. 10.0
+	Equal map[ObjectType]func(left, right *dynamodb.AttributeValue) bool
+}
+
+// equal compares the values with the override of their type and whether there is one
+func (o *EqualityOptions) equal(left, right Object) (bool, bool) {
+	if o == nil {
+		return false, false
+	}
+
+	eq, ok := o.Equal[left.Type()]
+	if !ok || left.Type() != right.Type() {
+		return false, false
+	}
+
+	leftVal, err := equalityAttributeValue(left)
+	if err != nil {
+		return false, false
+	}
+
+	rightVal, err := equalityAttributeValue(right)
+	if err != nil {
+		return false, false
+	}
+
+	return eq(leftVal, rightVal), true
+}
+
+// equalityAttributeValue converts the object keeping the original representation of the numbers
+func equalityAttributeValue(obj Object) (*dynamodb.AttributeValue, error) {
+	if n, ok := obj.(*Number); ok && n.decimal != "" {
+		decimal := n.decimal
+
+		return &dynamodb.AttributeValue{N: &decimal}, nil
+	}
+
+	return ObjectToAttributeValue(obj)
+}
+
 // Environment represents the execution enviroment
 type Environment struct {
 	store map[string]Object
+	// Aliases maps the expression attribute names to the attributes names, e.g. #n => name
+	Aliases map[string]string
+	// CompareHook optional hook used by the comparators before the default comparison, BETWEEN
+	// compares each bound with <= and IN compares each candidate with =
+	CompareHook CompareHook
+	// Equality optional overrides of the equality of the values of some types
+	Equality *EqualityOptions
+	// Lenient evaluates the comparators <, <=, > and >= between different types as false
+	// instead of an error, e.g. a number stored as a string compared with a number. The sets
+	// can not be ordered, so comparing them is still an error
+	Lenient bool
+	// shared results of the shared expressions, discarded when the variables change
+	shared map[*sharedExpression]Object
+	// deadline the evaluation fails after it when it is set
+	deadline time.Time
+	timedOut bool
 }
 
 // NewEnvironment creates a new enviroment
@@ -31,6 +95,15 @@ func (e *Environment) AddAttributes(attributes map[string]*dynamodb.AttributeVal
 	return nil
 }
 
+// ResolveName returns the attribute name of the given name when it is an alias
+func (e *Environment) ResolveName(name string) string {
+	if alias, ok := e.Aliases[name]; ok {
+		return alias
+	}
+
+	return name
+}
+
 // Get gets the value of the variable in the environment
 func (e *Environment) Get(name string) (Object, bool) {
 	obj, ok := e.store[name]
@@ -40,9 +113,39 @@ func (e *Environment) Get(name string) (Object, bool) {
 // Set assigns the value of the variable in the environment
 func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
+	e.shared = nil
+
 	return val
 }
 
+// Remove deletes the variable from the environment
+func (e *Environment) Remove(name string) {
+	delete(e.store, name)
+	e.shared = nil
+}
+
+// expired whether the deadline of the evaluation has passed, once it happens it keeps failing
+func (e *Environment) expired() bool {
+	if e.deadline.IsZero() || e.timedOut {
+		return e.timedOut
+	}
+
+	e.timedOut = time.Now().After(e.deadline)
+
+	return e.timedOut
+}
+
+// Attributes returns a copy of the variables in the environment
+func (e *Environment) Attributes() map[string]Object {
+	attributes := make(map[string]Object, len(e.store))
+
+	for n, v := range e.store {
+		attributes[n] = v
+	}
+
+	return attributes
+}
+
 // Set assigns the value of the variable in the environment
 func (e *Environment) String() string {
 	out := []string{}
@@ -8,6 +8,10 @@ import (
 
 // Eval runs the expression in the environment
 func Eval(n Node, env *Environment) Object {
+	if env.expired() {
+		return newError("the evaluation exceeded the time limit")
+	}
+
 	switch node := n.(type) {
 	case *DynamoExpression:
 		return Eval(node.Statement, env)
@@ -19,10 +23,24 @@ func Eval(n Node, env *Environment) Object {
 		return evalInfixParts(node, env)
 	case *BetweenExpression:
 		return evalBetween(node, env)
+	case *InExpression:
+		return evalIn(node, env)
 	case *CallExpression:
 		return evalFunctionCall(node, env)
 	case *Identifier:
 		return evalIdentifier(node, env)
+	case *IndexExpression:
+		return evalIndexExpression(node, env)
+	case *BoolLiteral:
+		return nativeBoolToBooleanObject(node.Value)
+	case *NumberLiteral:
+		return &Number{Value: node.Value, decimal: node.Token.Literal}
+	case *StringLiteral:
+		return &String{Value: node.Value}
+	case *sharedExpression:
+		return evalShared(node, env)
+	case *attributeAccessor:
+		return evalAccessor(node, env)
 	}
 
 	return newError("unsupported expression: %s", n.String())
@@ -57,7 +75,13 @@ func isString(obj Object) bool {
 }
 
 func isUndefined(obj Object) bool {
-	return obj == nil || obj == NULL
+	if obj == nil {
+		return true
+	}
+
+	null, ok := obj.(*Null)
+
+	return ok && null.undefined
 }
 
 func isComparable(obj Object) bool {
@@ -84,9 +108,26 @@ func evalPrefixExpression(node *PrefixExpression, env *Environment) Object {
 		return evalBangOperatorExpression(right)
 	}
 
+	if node.Operator == MINUS {
+		return evalMinusPrefixOperatorExpression(node, right)
+	}
+
 	return newError("unknown operator: %s %s", node.Operator, right.Type())
 }
 
+func evalMinusPrefixOperatorExpression(node *PrefixExpression, right Object) Object {
+	if isUndefined(right) {
+		return newError("the provided expression refers to an attribute that does not exist in the item: %s", node.Right.String())
+	}
+
+	num, ok := right.(*Number)
+	if !ok {
+		return newError("an operand in the update expression has an incorrect data type: -%s", right.Type())
+	}
+
+	return negateNumber(num)
+}
+
 func evalBangOperatorExpression(right Object) Object {
 	switch right {
 	case TRUE:
@@ -99,6 +140,10 @@ func evalBangOperatorExpression(right Object) Object {
 }
 
 func evalInfixParts(node *InfixExpression, env *Environment) Object {
+	if node.Operator == AND || node.Operator == OR {
+		return evalLogicalChain(node, env)
+	}
+
 	left := Eval(node.Left, env)
 	if isError(left) {
 		return left
@@ -109,9 +154,152 @@ func evalInfixParts(node *InfixExpression, env *Environment) Object {
 		return right
 	}
 
+	if node.Operator == PLUS || node.Operator == MINUS {
+		return evalArithmeticExpression(node, left, right)
+	}
+
+	if env.CompareHook != nil {
+		if result, ok := evalCompareHook(env.CompareHook, node.Operator, left, right); ok {
+			return result
+		}
+	}
+
+	if env.Equality != nil && isEqualityOverridable(node.Operator, left, right) {
+		equal := equalObjectWith(left, right, env.Equality)
+
+		return nativeBoolToBooleanObject(equal == (node.Operator == EQ))
+	}
+
+	if env.Lenient && isRelationalMismatch(node.Operator, left, right) {
+		return FALSE
+	}
+
 	return evalInfixExpression(node.Operator, left, right)
 }
 
+// evalArithmeticExpression adds or subtracts numbers, the attributes must exist in the item
+func evalArithmeticExpression(node *InfixExpression, left, right Object) Object {
+	if isUndefined(left) {
+		return newError("the provided expression refers to an attribute that does not exist in the item: %s", node.Left.String())
+	}
+
+	if isUndefined(right) {
+		return newError("the provided expression refers to an attribute that does not exist in the item: %s", node.Right.String())
+	}
+
+	l, leftOk := left.(*Number)
+	r, rightOk := right.(*Number)
+
+	if !leftOk || !rightOk {
+		return newError("an operand in the update expression has an incorrect data type: %s %s %s", left.Type(), node.Operator, right.Type())
+	}
+
+	if node.Operator == MINUS {
+		return subtractNumbers(l, r)
+	}
+
+	return addNumbers(l, r)
+}
+
+// isOrdering whether the operator compares the order of the values, the sets are unordered
+func isOrdering(operator string) bool {
+	switch operator {
+	case LT, LTE, GT, GTE:
+		return true
+	}
+
+	return false
+}
+
+// isEqualityOverridable whether the values are compared with = or <> being of the same type
+func isEqualityOverridable(operator string, left, right Object) bool {
+	return (operator == EQ || operator == NotEQ) && !isUndefined(left) && !isUndefined(right) && left.Type() == right.Type()
+}
+
+// isRelationalMismatch whether the values would be compared with <, <=, > or >= being of different types,
+// the sets are excluded because they can not be ordered even in lenient mode
+func isRelationalMismatch(operator string, left, right Object) bool {
+	if setTypes[left.Type()] || setTypes[right.Type()] {
+		return false
+	}
+
+	return isOrdering(operator) && !isUndefined(left) && !isUndefined(right) && left.Type() != right.Type()
+}
+
+// evalCompareHook compares the values with the hook, the missing attributes and the
+// values without an attribute value representation are never sent to the hook
+func evalCompareHook(hook CompareHook, operator string, left, right Object) (Object, bool) {
+	if isUndefined(left) || isUndefined(right) {
+		return nil, false
+	}
+
+	leftVal, err := ObjectToAttributeValue(left)
+	if err != nil {
+		return nil, false
+	}
+
+	rightVal, err := ObjectToAttributeValue(right)
+	if err != nil {
+		return nil, false
+	}
+
+	cmp, handled, err := hook(operator, leftVal, rightVal)
+	if err != nil {
+		return newError("compare hook failed: %s %s %s: %s", left.Type(), operator, right.Type(), err.Error()), true
+	}
+
+	if !handled {
+		return nil, false
+	}
+
+	switch operator {
+	case EQ:
+		return nativeBoolToBooleanObject(cmp == 0), true
+	case NotEQ:
+		return nativeBoolToBooleanObject(cmp != 0), true
+	case LT:
+		return nativeBoolToBooleanObject(cmp < 0), true
+	case LTE:
+		return nativeBoolToBooleanObject(cmp <= 0), true
+	case GT:
+		return nativeBoolToBooleanObject(cmp > 0), true
+	case GTE:
+		return nativeBoolToBooleanObject(cmp >= 0), true
+	}
+
+	return nil, false
+}
+
+// evalLogicalChain evaluates the terms of the AND/OR chain one after the other instead of
+// recursively, it short-circuits when the result is already known
+func evalLogicalChain(node *InfixExpression, env *Environment) Object {
+	terms := flattenLogical(node, node.Operator)
+
+	result := Eval(terms[0], env)
+
+	for _, term := range terms[1:] {
+		if isError(result) {
+			return result
+		}
+
+		switch {
+		case node.Operator == AND && result == FALSE:
+			return FALSE
+		case node.Operator == OR && result == TRUE:
+			return TRUE
+		}
+
+		right := Eval(term, env)
+		if isError(right) {
+			return right
+		}
+
+		result = evalInfixExpression(node.Operator, result, right)
+	}
+
+	return result
+}
+
 func evalInfixExpression(operator string, left, right Object) Object {
 	switch {
 	case isComparable(left) && isComparable(right):
@@ -124,6 +312,13 @@ func evalInfixExpression(operator string, left, right Object) Object {
 		return nativeBoolToBooleanObject(equalObject(left, right))
 	case operator == "<>":
 		return nativeBoolToBooleanObject(!equalObject(left, right))
+	case isOrdering(operator) && (setTypes[left.Type()] || setTypes[right.Type()]):
+		setType := left.Type()
+		if !setTypes[setType] {
+			setType = right.Type()
+		}
+
+		return newError("incorrect operand type for operator or function; operator or function: %s, operand type: %s", operator, setType)
 	case !matchTypes(left.Type(), left, right):
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -147,7 +342,11 @@ func evalNullInfixExpression(operator string, left, right Object) Object {
 		return FALSE
 	}
 
-	return FALSE
+	if isUndefined(left) || isUndefined(right) {
+		return FALSE
+	}
+
+	return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 }
 
 func evalComparableInfixExpression(operator string, left, right Object) Object {
@@ -155,6 +354,17 @@ func evalComparableInfixExpression(operator string, left, right Object) Object {
 		return FALSE
 	}
 
+	if !matchTypes(left.Type(), left, right) {
+		switch operator {
+		case "=":
+			return FALSE
+		case "<>":
+			return TRUE
+		}
+
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	}
+
 	switch left.Type() {
 	case ObjectTypeNumber:
 		return evalNumberInfixExpression(operator, left, right)
@@ -256,15 +466,57 @@ func evalBooleanInfixExpression(operator string, left, right Object) Object {
 }
 
 func equalObject(left, right Object) bool {
+	return equalObjectWith(left, right, nil)
+}
+
+// equalObjectWith compares the values deeply using the overrides of the options when they are set
+func equalObjectWith(left, right Object, opts *EqualityOptions) bool {
 	if !matchTypes(left.Type(), left, right) {
 		return false
 	}
 
+	if equal, ok := opts.equal(left, right); ok {
+		return equal
+	}
+
+	// the numbers are compared by value, e.g. 1 = 1.0
+	switch l := left.(type) {
+	case *Number:
+		return l.Value == right.(*Number).Value
+	case *Map:
+		r := right.(*Map)
+		if len(l.Value) != len(r.Value) {
+			return false
+		}
+
+		for k, v := range l.Value {
+			rv, ok := r.Value[k]
+			if !ok || !equalObjectWith(v, rv, opts) {
+				return false
+			}
+		}
+
+		return true
+	case *List:
+		r := right.(*List)
+		if len(l.Value) != len(r.Value) {
+			return false
+		}
+
+		for i, v := range l.Value {
+			if !equalObjectWith(v, r.Value[i], opts) {
+				return false
+			}
+		}
+
+		return true
+	}
+
 	return reflect.DeepEqual(left, right)
 }
 
 func evalIdentifier(node *Identifier, env *Environment) Object {
-	val, ok := env.Get(node.Value)
+	val, ok := env.Get(env.ResolveName(node.Value))
 	if !ok {
 		return NULL
 	}
@@ -272,6 +524,34 @@ func evalIdentifier(node *Identifier, env *Environment) Object {
 	return val
 }
 
+func evalIndexExpression(node *IndexExpression, env *Environment) Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	if node.Token.Type == DOT {
+		m, ok := left.(*Map)
+		if !ok {
+			return NULL
+		}
+
+		val, ok := m.Value[env.ResolveName(node.Key.Value)]
+		if !ok {
+			return NULL
+		}
+
+		return val
+	}
+
+	list, ok := left.(*List)
+	if !ok || node.Index >= len(list.Value) {
+		return NULL
+	}
+
+	return list.Value[node.Index]
+}
+
 func evalBetween(node *BetweenExpression, env *Environment) Object {
 	val := evalBetweenOperand(node.Left, env)
 	if isError(val) {
@@ -288,6 +568,11 @@ func evalBetween(node *BetweenExpression, env *Environment) Object {
 		return max
 	}
 
+	// the bounds must be compatible even when the attribute does not exist
+	if !isUndefined(min) && !isUndefined(max) && min.Type() != max.Type() {
+		return newError("mismatch type: BETWEEN operands must have the same type")
+	}
+
 	if isUndefined(val) || isUndefined(min) || isUndefined(max) {
 		return FALSE
 	}
@@ -296,11 +581,39 @@ func evalBetween(node *BetweenExpression, env *Environment) Object {
 		return newError("mismatch type: BETWEEN operands must have the same type")
 	}
 
+	if env.CompareHook != nil {
+		return compareRangeWithHook(env.CompareHook, val, min, max)
+	}
+
 	b := compareRange(val, min, max)
 
 	return b
 }
 
+// compareRangeWithHook compares the value with each bound using the <= operator of the hook,
+// the bounds not handled by the hook use the default comparison
+func compareRangeWithHook(hook CompareHook, value, min, max Object) Object {
+	left, ok := evalCompareHook(hook, LTE, min, value)
+	if !ok {
+		left = evalComparableInfixExpression(LTE, min, value)
+	}
+
+	if isError(left) {
+		return left
+	}
+
+	right, ok := evalCompareHook(hook, LTE, value, max)
+	if !ok {
+		right = evalComparableInfixExpression(LTE, value, max)
+	}
+
+	if isError(right) {
+		return right
+	}
+
+	return evalBooleanInfixExpression(AND, left, right)
+}
+
 func compareRange(value, min, max Object) Object {
 	switch val := value.(type) {
 	case *Number:
@@ -324,12 +637,11 @@ func compareRange(value, min, max Object) Object {
 }
 
 func evalBetweenOperand(exp Expression, env *Environment) Object {
-	identifier, ok := exp.(*Identifier)
-	if !ok {
-		return newError("identifier expected: got %q", exp.String())
+	val := Eval(exp, env)
+	if isError(val) {
+		return val
 	}
 
-	val := evalIdentifier(identifier, env)
 	if !comparableTypes[val.Type()] && !isUndefined(val) {
 		return newError("unexpected type: %q should be a comparable type(N,S,B) got %q", exp.String(), val.Type())
 	}
@@ -337,18 +649,66 @@ func evalBetweenOperand(exp Expression, env *Environment) Object {
 	return val
 }
 
+func evalIn(node *InExpression, env *Environment) Object {
+	val := Eval(node.Left, env)
+	if isError(val) {
+		return val
+	}
+
+	// the hashed candidates can not use the equality overrides nor the compare hook
+	if node.values != nil && env.Equality == nil && env.CompareHook == nil {
+		key, ok := hashKey(val)
+
+		return nativeBoolToBooleanObject(ok && node.values[key])
+	}
+
+	candidates := evalExpressions(node.Candidates, env)
+	if len(candidates) == 1 && isError(candidates[0]) {
+		return candidates[0]
+	}
+
+	if isUndefined(val) {
+		return FALSE
+	}
+
+	for _, candidate := range candidates {
+		if env.CompareHook != nil {
+			// the candidates are compared with the = operator of the hook
+			if result, ok := evalCompareHook(env.CompareHook, EQ, val, candidate); ok {
+				if result != FALSE {
+					return result
+				}
+
+				continue
+			}
+		}
+
+		if equalObjectWith(val, candidate, env.Equality) {
+			return TRUE
+		}
+	}
+
+	return FALSE
+}
+
 func evalFunctionCall(node *CallExpression, env *Environment) Object {
 	fn := evalFunctionCallIdentifer(node, env)
 	if isError(fn) {
 		return fn
 	}
 
+	function := fn.(*Function)
+
+	if len(node.Arguments) != function.Arity {
+		return newError("incorrect number of operands for operator or function; operator or function: %s, number of operands: %d", function.Name, len(node.Arguments))
+	}
+
 	args := evalExpressions(node.Arguments, env)
 	if len(args) == 1 && isError(args[0]) {
 		return args[0]
 	}
 
-	return fn.(*Function).Value(args...)
+	return function.Value(args...)
 }
 
 func evalFunctionCallIdentifer(node *CallExpression, env *Environment) Object {
@@ -381,3 +741,28 @@ func evalExpressions(exps []Expression, env *Environment) []Object {
 
 	return result
 }
+
+func evalAccessor(node *attributeAccessor, env *Environment) Object {
+	val, ok := env.Get(node.key)
+	if !ok {
+		return NULL
+	}
+
+	return val
+}
+
+func evalShared(node *sharedExpression, env *Environment) Object {
+	if obj, ok := env.shared[node]; ok {
+		return obj
+	}
+
+	obj := Eval(node.Expression, env)
+
+	if env.shared == nil {
+		env.shared = map[*sharedExpression]Object{}
+	}
+
+	env.shared[node] = obj
+
+	return obj
+}
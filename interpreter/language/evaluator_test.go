@@ -1,6 +1,8 @@
 package language
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -22,6 +24,10 @@ func TestEval(t *testing.T) {
 		{":a = :b", FALSE},
 		{":a AND :b", FALSE},
 		{":a OR :b", TRUE},
+		{":a and not :b", TRUE},
+		{":b Or Not :a", FALSE},
+		{":x between :x aND :y", TRUE},
+		{":x in (:y, :z)", FALSE},
 		// Numbers
 		{":s = :b", FALSE},
 		{":x < :y", TRUE},
@@ -62,6 +68,9 @@ func TestEval(t *testing.T) {
 		{":otherNil <> :nil", FALSE},
 		{":notFound = :nil", FALSE},
 		{":notFound <> :nil", TRUE},
+		{":nil = :s", FALSE},
+		{":nil <> :s", TRUE},
+		{":notFound > :nil", FALSE},
 		// BETWEEN
 		{":y BETWEEN :x AND :z", TRUE},
 		{":txtB BETWEEN :txtA AND :txtC", TRUE},
@@ -213,6 +222,1205 @@ func TestEvalFunctions(t *testing.T) {
 	}
 }
 
+func TestEvalListIndexes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"items[0] < items[1]", TRUE},
+		{"items[1] < items[0]", FALSE},
+		{"items[1] >= items[0]", TRUE},
+		{"items[0] = items[0]", TRUE},
+		{"items[0] < items[5]", FALSE},
+		{"items[5] < items[0]", FALSE},
+		{"items[0] = items[2]", FALSE},
+		{"items[0] <> items[2]", TRUE},
+		{"matrix[1][0] > items[1]", TRUE},
+		{"matrix[0][3] > items[1]", FALSE},
+		{"name[0] = items[0]", FALSE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"name": {S: aws.String("pikachu")},
+		"items": {
+			L: []*dynamodb.AttributeValue{
+				{N: aws.String("1")},
+				{N: aws.String("2")},
+				{S: aws.String("1")},
+			},
+		},
+		"matrix": {
+			L: []*dynamodb.AttributeValue{
+				{L: []*dynamodb.AttributeValue{{N: aws.String("1")}}},
+				{L: []*dynamodb.AttributeValue{{N: aws.String("3")}}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+
+	evaluated := testEval(t, "items[0] < items[2]", env)
+
+	errObj, ok := evaluated.(*Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Message != "type mismatch: N < S" {
+		t.Errorf("wrong error message. expected=%q, got=%q", "type mismatch: N < S", errObj.Message)
+	}
+}
+
+func TestEvalDocumentPaths(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"orders[0].total > :t", TRUE},
+		{"orders[1].total > :t", FALSE},
+		{"list[0].b = :x", TRUE},
+		{"a.b[1].c = :x", TRUE},
+		{"a.b[0].c = :x", FALSE},
+		{"orders[0].missing > :t", FALSE},
+		{"orders[0].missing = :t", FALSE},
+		{"orders[5].total > :t", FALSE},
+		{"orders.total > :t", FALSE},
+		{"a.b.c = :x", FALSE},
+		{"attribute_exists(orders[0].total)", TRUE},
+		{"attribute_not_exists(orders[0].missing)", TRUE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		":t": {N: aws.String("10")},
+		":x": {S: aws.String("x")},
+		"orders": {
+			L: []*dynamodb.AttributeValue{
+				{M: map[string]*dynamodb.AttributeValue{"total": {N: aws.String("20")}}},
+				{M: map[string]*dynamodb.AttributeValue{"total": {N: aws.String("5")}}},
+			},
+		},
+		"list": {
+			L: []*dynamodb.AttributeValue{
+				{M: map[string]*dynamodb.AttributeValue{"b": {S: aws.String("x")}}},
+			},
+		},
+		"a": {
+			M: map[string]*dynamodb.AttributeValue{
+				"b": {
+					L: []*dynamodb.AttributeValue{
+						{M: map[string]*dynamodb.AttributeValue{"c": {S: aws.String("y")}}},
+						{M: map[string]*dynamodb.AttributeValue{"c": {S: aws.String("x")}}},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalDeepDocumentPaths(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"data.rows[2].cols[1].value = :v", TRUE},
+		{"data.rows[2].cols[0].value = :v", FALSE},
+		{"attribute_exists(data.rows[2].cols[1].value)", TRUE},
+		{"data.rows[2].cols[1].missing = :v", FALSE},
+		{"attribute_not_exists(data.rows[2].cols[1].missing)", TRUE},
+		{"data.rows[3].cols[1].value = :v", FALSE},
+		{"data.rows[2].cols[5].value = :v", FALSE},
+		{"data.flat[2].cols[1].value = :v", FALSE},
+		{"data.rows[0].cols[1].value = :v", FALSE},
+		{"data.rows.cols[1].value = :v", FALSE},
+		{"attribute_exists(data.flat[2].cols[1].value)", FALSE},
+	}
+
+	row := func(cols ...*dynamodb.AttributeValue) *dynamodb.AttributeValue {
+		return &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"cols": {L: cols}}}
+	}
+
+	col := func(val string) *dynamodb.AttributeValue {
+		return &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"value": {S: aws.String(val)}}}
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		":v": {S: aws.String("target")},
+		"data": {
+			M: map[string]*dynamodb.AttributeValue{
+				"rows": {
+					L: []*dynamodb.AttributeValue{
+						// the intermediate cols is not a list
+						{M: map[string]*dynamodb.AttributeValue{"cols": {S: aws.String("cols")}}},
+						row(col("a")),
+						row(col("b"), col("target")),
+					},
+				},
+				// rows with the wrong type
+				"flat": {S: aws.String("rows")},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalIn(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"a IN (:x, :y)", TRUE},
+		{"a IN (:y, :z)", FALSE},
+		{"NOT a IN (:x, :y)", FALSE},
+		{"NOT a IN (:y, :z)", TRUE},
+		{"NOT (a IN (:y, :z))", TRUE},
+		{"n IN (:x, :n)", TRUE},
+		{"notFound IN (:x, :y)", FALSE},
+		{"NOT notFound IN (:x, :y)", TRUE},
+		{"a IN (:y, a)", TRUE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"a":  {S: aws.String("x")},
+		"n":  {N: aws.String("1")},
+		":x": {S: aws.String("x")},
+		":y": {S: aws.String("y")},
+		":z": {S: aws.String("z")},
+		":n": {N: aws.String("1")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalSizeOfSets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"size(numbers) > :two", TRUE},
+		{"size(numbers) = :three", TRUE},
+		{"size(numbers) > :three", FALSE},
+		{"size(strings) = :two", TRUE},
+		{"size(strings) > :two", FALSE},
+		{"size(binaries) >= :three", TRUE},
+		{"size(binaries) < :three", FALSE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"numbers":  {NS: []*string{aws.String("1"), aws.String("2"), aws.String("3")}},
+		"strings":  {SS: []*string{aws.String("a"), aws.String("b"), aws.String("a")}},
+		"binaries": {BS: [][]byte{[]byte("a"), []byte("b"), []byte("c")}},
+		":two":     {N: aws.String("2")},
+		":three":   {N: aws.String("3")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalSetComparators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"ns1 = ns2", TRUE},
+		{"ns1 <> ns2", FALSE},
+		{"ns1 = ns3", FALSE},
+		{"ns1 <> ns3", TRUE},
+		{"ns1 = ss", FALSE},
+		{"ns1 = missing", FALSE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"ns1": {NS: []*string{aws.String("1"), aws.String("2")}},
+		"ns2": {NS: []*string{aws.String("2"), aws.String("1.0")}},
+		"ns3": {NS: []*string{aws.String("1")}},
+		"ss":  {SS: []*string{aws.String("1"), aws.String("2")}},
+		":n":  {N: aws.String("1")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"ns1 > ns2", "incorrect operand type for operator or function; operator or function: >, operand type: NS"},
+		{"ns1 <= ns3", "incorrect operand type for operator or function; operator or function: <=, operand type: NS"},
+		{":n < ss", "incorrect operand type for operator or function; operator or function: <, operand type: SS"},
+	}
+
+	for _, tt := range errorTests {
+		for _, lenient := range []bool{false, true} {
+			env.Lenient = lenient
+
+			errObj, ok := testEval(t, tt.input, env).(*Error)
+			if !ok {
+				t.Fatalf("expected an error for %q with lenient=%v", tt.input, lenient)
+			}
+
+			if errObj.Message != tt.expected {
+				t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestEvalGroupedOperands(t *testing.T) {
+	tests := []struct {
+		grouped string
+		plain   string
+	}{
+		{"(a) = (:b)", "a = :b"},
+		{"(a) <> (:b)", "a <> :b"},
+		{"(a = :b) AND (c = :d)", "a = :b AND c = :d"},
+		{"(a = :b) AND (c = :b)", "a = :b AND c = :b"},
+		{"((((a)))) = (((:b)))", "a = :b"},
+		{"((a) < ((:d))) OR ((c) = (:b))", "a < :d OR c = :b"},
+		{"(size((c))) > (:n)", "size(c) > :n"},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"a":  {N: aws.String("1")},
+		"c":  {S: aws.String("two")},
+		":b": {N: aws.String("1")},
+		":d": {S: aws.String("two")},
+		":n": {N: aws.String("2")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		grouped := testEval(t, tt.grouped, env)
+		plain := testEval(t, tt.plain, env)
+
+		if grouped.Inspect() != plain.Inspect() {
+			t.Errorf("grouped expression %q evaluated differently than %q. got=%s, want=%s", tt.grouped, tt.plain, grouped.Inspect(), plain.Inspect())
+		}
+	}
+}
+
+func TestEvalSetScalarEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"tags = :s", FALSE},
+		{"tags <> :s", TRUE},
+		{":s = tags", FALSE},
+		{"tags = :n", FALSE},
+		{"NOT tags = :s", TRUE},
+		{"tags IN (:s, :n)", FALSE},
+		{"tags = :s OR tags = :tags", TRUE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"tags":  {SS: []*string{aws.String("a")}},
+		":tags": {SS: []*string{aws.String("a")}},
+		":s":    {S: aws.String("a")},
+		":n":    {N: aws.String("1")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, lenient := range []bool{false, true} {
+		env.Lenient = lenient
+
+		for _, tt := range tests {
+			evaluated := testEval(t, tt.input, env)
+			if evaluated != tt.expected {
+				t.Errorf("result has wrong value for %q (lenient=%v). got=%v, want=%v", tt.input, lenient, evaluated, tt.expected)
+			}
+		}
+	}
+
+	evaluated := testEvalLenient(t, `tags = "a" OR tags = 1`, env)
+	if evaluated != FALSE {
+		t.Errorf("result has wrong value for the literals. got=%v, want=%v", evaluated, FALSE)
+	}
+}
+
+func TestEvalLenientBoolLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"active = true", TRUE},
+		{"active = TRUE", TRUE},
+		{"active = false", FALSE},
+		{"active <> False", TRUE},
+		{"active != false", TRUE},
+		{"active != true", FALSE},
+		{"inactive = false", TRUE},
+		{"name = true", FALSE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"active":   {BOOL: aws.Bool(true)},
+		"inactive": {BOOL: aws.Bool(false)},
+		"name":     {S: aws.String("true")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalLenient(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+
+	evaluated := testEvalLenient(t, "active > true", env)
+
+	errObj, ok := evaluated.(*Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Message != "unknown operator: BOOL > BOOL" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestEvalLenientLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"age > 21", TRUE},
+		{"age = 30", TRUE},
+		{"age = 30.0", TRUE},
+		{"price < 10.25", TRUE},
+		{"price > 10.25", FALSE},
+		{`status = "ACTIVE"`, TRUE},
+		{`status = "active"`, FALSE},
+		{`quote = "say \"hi\""`, TRUE},
+		{`begins_with(status, "ACT")`, TRUE},
+		{`age = "30"`, FALSE},
+		{"age BETWEEN 18 AND 40", TRUE},
+		{"age - 31 = -1", TRUE},
+		{":x - -1 = age", TRUE},
+		{`missing = "ACTIVE"`, FALSE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"age":    {N: aws.String("30")},
+		"price":  {N: aws.String("9.99")},
+		"status": {S: aws.String("ACTIVE")},
+		"quote":  {S: aws.String(`say "hi"`)},
+		":x":     {N: aws.String("29")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalLenient(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func testEvalLenient(t *testing.T, input string, env *Environment) Object {
+	l := NewLexer(input)
+	p := NewLenientParser(l)
+	program := p.ParseDynamoExpression()
+
+	if len(p.errors) != 0 {
+		t.Fatalf("parsing %q failed: %s", input, strings.Join(p.errors, ";\n"))
+	}
+
+	return Eval(program, env)
+}
+
+func TestEvalManyDisjuncts(t *testing.T) {
+	terms := make([]string, 50)
+	values := map[string]*dynamodb.AttributeValue{}
+
+	for i := range terms {
+		terms[i] = fmt.Sprintf("id = :v%d", i)
+		values[fmt.Sprintf(":v%d", i)] = &dynamodb.AttributeValue{N: aws.String(fmt.Sprint(i))}
+	}
+
+	input := strings.Join(terms, " OR ")
+
+	tests := []struct {
+		id       string
+		expected Object
+	}{
+		{"0", TRUE},
+		{"49", TRUE},
+		{"50", FALSE},
+	}
+
+	for _, tt := range tests {
+		env := NewEnvironment()
+
+		err := env.AddAttributes(values)
+		if err != nil {
+			t.Fatalf("error adding attributes %#v", err)
+		}
+
+		err = env.AddAttributes(map[string]*dynamodb.AttributeValue{"id": {N: aws.String(tt.id)}})
+		if err != nil {
+			t.Fatalf("error adding attributes %#v", err)
+		}
+
+		evaluated := testEval(t, input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for id=%s. got=%v, want=%v", tt.id, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalCompareHook(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"name = :lower", TRUE},
+		{"name <> :lower", FALSE},
+		{"name < :other", TRUE},
+		{"name >= :other", FALSE},
+		{"age = :age", TRUE},
+		{"missing = :lower", FALSE},
+		{"name BETWEEN :lower AND :other", TRUE},
+		{"name BETWEEN :other AND :other", FALSE},
+		{"age BETWEEN :age AND :age", TRUE},
+		{"name IN (:other, :lower)", TRUE},
+		{"name IN (:other)", FALSE},
+		{"age IN (:other, :age)", TRUE},
+	}
+
+	env := NewEnvironment()
+	env.CompareHook = func(op string, left, right *dynamodb.AttributeValue) (int, bool, error) {
+		if left.S == nil || right.S == nil {
+			return 0, false, nil
+		}
+
+		return strings.Compare(strings.ToLower(*left.S), strings.ToLower(*right.S)), true, nil
+	}
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"name":   {S: aws.String("Alice")},
+		"age":    {N: aws.String("30")},
+		":lower": {S: aws.String("alice")},
+		":other": {S: aws.String("BOB")},
+		":age":   {N: aws.String("30")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+
+	env.CompareHook = func(op string, left, right *dynamodb.AttributeValue) (int, bool, error) {
+		return 0, false, errors.New("unsupported collation")
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"name = :lower", "ERROR: compare hook failed: S = S: unsupported collation"},
+		{"name BETWEEN :lower AND :other", "ERROR: compare hook failed: S <= S: unsupported collation"},
+		{"name IN (:lower)", "ERROR: compare hook failed: S = S: unsupported collation"},
+	}
+
+	for _, tt := range errorTests {
+		evaluated := testEval(t, tt.input, env)
+		if !isError(evaluated) || evaluated.Inspect() != tt.expected {
+			t.Errorf("unexpected result for a failing hook with %q. got=%s", tt.input, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvalNumberEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{":one = :oneDecimal", TRUE},
+		{":one <> :oneDecimal", FALSE},
+		{":one IN (:two, :oneDecimal)", TRUE},
+		{":map = :mapDecimal", TRUE},
+		{":list = :listDecimal", TRUE},
+		{"contains(:list, :oneDecimal)", TRUE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		":one":         {N: aws.String("1")},
+		":oneDecimal":  {N: aws.String("1.0")},
+		":two":         {N: aws.String("2")},
+		":map":         {M: map[string]*dynamodb.AttributeValue{"n": {N: aws.String("1")}}},
+		":mapDecimal":  {M: map[string]*dynamodb.AttributeValue{"n": {N: aws.String("1.00")}}},
+		":list":        {L: []*dynamodb.AttributeValue{{N: aws.String("1")}}},
+		":listDecimal": {L: []*dynamodb.AttributeValue{{N: aws.String("1e0")}}},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalEqualityOptions(t *testing.T) {
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"total":       {N: aws.String("10")},
+		"name":        {S: aws.String("ten")},
+		":ten":        {N: aws.String("10.0")},
+		":eleven":     {N: aws.String("11")},
+		":name":       {S: aws.String("ten")},
+		":map":        {M: map[string]*dynamodb.AttributeValue{"n": {N: aws.String("10")}}},
+		":mapDecimal": {M: map[string]*dynamodb.AttributeValue{"n": {N: aws.String("10.00")}}},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	// the numbers are compared by their text, e.g. 10 and 10.0 are different
+	env.Equality = &EqualityOptions{
+		Equal: map[ObjectType]func(left, right *dynamodb.AttributeValue) bool{
+			ObjectTypeNumber: func(left, right *dynamodb.AttributeValue) bool {
+				return *left.N == *right.N
+			},
+		},
+	}
+
+	exact := []struct {
+		input    string
+		expected Object
+	}{
+		{"total = :ten", FALSE},
+		{"total <> :ten", TRUE},
+		{"total IN (:eleven, :ten)", FALSE},
+		{":map = :mapDecimal", FALSE},
+		{"name = :name", TRUE},
+		{"missing <> :ten", FALSE},
+	}
+
+	for _, tt := range exact {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+
+	// the trailing zeros of the decimals are ignored, e.g. 10 and 10.0 are equal
+	env.Equality.Equal[ObjectTypeNumber] = func(left, right *dynamodb.AttributeValue) bool {
+		trim := func(n string) string {
+			if !strings.Contains(n, ".") {
+				return n
+			}
+
+			return strings.TrimSuffix(strings.TrimRight(n, "0"), ".")
+		}
+
+		return trim(*left.N) == trim(*right.N)
+	}
+
+	semantic := []struct {
+		input    string
+		expected Object
+	}{
+		{"total = :ten", TRUE},
+		{"total <> :ten", FALSE},
+		{"total IN (:eleven, :ten)", TRUE},
+		{":map = :mapDecimal", TRUE},
+		{"total = :eleven", FALSE},
+	}
+
+	for _, tt := range semantic {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalRepeatedAttribute(t *testing.T) {
+	tests := []struct {
+		price    string
+		expected Object
+	}{
+		{"15", TRUE},
+		{"5", FALSE},
+		{"25", FALSE},
+		{"10", FALSE},
+		{"20", FALSE},
+		{"10.5", TRUE},
+		{"19.99", TRUE},
+	}
+
+	inputs := []string{
+		"price > :lo AND price < :hi",
+		"price < :hi AND price > :lo",
+		"NOT (price <= :lo OR price >= :hi)",
+	}
+
+	for _, tt := range tests {
+		env := NewEnvironment()
+
+		err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+			"price": {N: aws.String(tt.price)},
+			":lo":   {N: aws.String("10")},
+			":hi":   {N: aws.String("20")},
+		})
+		if err != nil {
+			t.Fatalf("error adding attributes %#v", err)
+		}
+
+		for _, input := range inputs {
+			evaluated := testEval(t, input, env)
+			if evaluated != tt.expected {
+				t.Errorf("result has wrong value for %q with price=%s. got=%v, want=%v", input, tt.price, evaluated, tt.expected)
+			}
+		}
+
+		inclusive := testEval(t, "price >= :lo AND price <= :hi", env)
+		if expected := nativeBoolToBooleanObject(tt.price != "5" && tt.price != "25"); inclusive != expected {
+			t.Errorf("result has wrong value for the inclusive range with price=%s. got=%v, want=%v", tt.price, inclusive, expected)
+		}
+	}
+}
+
+func TestEvalNumericLookingNames(t *testing.T) {
+	env := NewEnvironment()
+	env.Aliases = map[string]string{"#p": "1stPlace"}
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"1stPlace": {S: aws.String("alice")},
+		":winner":  {S: aws.String("alice")},
+		":len":     {N: aws.String("5")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"#p = :winner", TRUE},
+		{"attribute_exists(#p)", TRUE},
+		{"begins_with(#p, :winner) AND size(#p) = :len", TRUE},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalMapPlaceholderEquality(t *testing.T) {
+	config := func(keys []string, retries string, hosts ...string) *dynamodb.AttributeValue {
+		values := map[string]*dynamodb.AttributeValue{
+			"retries": {N: aws.String(retries)},
+			"hosts":   {L: []*dynamodb.AttributeValue{}},
+			"tls":     {M: map[string]*dynamodb.AttributeValue{"enabled": {BOOL: aws.Bool(true)}}},
+		}
+
+		for _, host := range hosts {
+			values["hosts"].L = append(values["hosts"].L, &dynamodb.AttributeValue{S: aws.String(host)})
+		}
+
+		m := map[string]*dynamodb.AttributeValue{}
+		for _, k := range keys {
+			m[k] = values[k]
+		}
+
+		return &dynamodb.AttributeValue{M: m}
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"config":      config([]string{"retries", "hosts", "tls"}, "3", "a", "b"),
+		":same":       config([]string{"tls", "hosts", "retries"}, "3", "a", "b"),
+		":decimal":    config([]string{"hosts", "tls", "retries"}, "3.0", "a", "b"),
+		":hostsOrder": config([]string{"retries", "hosts", "tls"}, "3", "b", "a"),
+		":deep":       config([]string{"retries", "hosts", "tls"}, "3", "a", "c"),
+		":fewer":      config([]string{"retries", "hosts"}, "3", "a", "b"),
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"config = :same", TRUE},
+		{"config = :decimal", TRUE},
+		{"config <> :same", FALSE},
+		{"config = :hostsOrder", FALSE},
+		{"config = :deep", FALSE},
+		{"config <> :deep", TRUE},
+		{"config = :fewer", FALSE},
+		{"config IN (:deep, :same)", TRUE},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalStringStoredNumber(t *testing.T) {
+	tests := []struct {
+		input   string
+		strict  string
+		lenient Object
+	}{
+		{"count > :n", "type mismatch: S > N", FALSE},
+		{"count <= :n", "type mismatch: S <= N", FALSE},
+		{":n < count", "type mismatch: N < S", FALSE},
+		{"count > :n OR count = :str", "type mismatch: S > N", TRUE},
+		{"count = :n", "", FALSE},
+		{"count <> :n", "", TRUE},
+	}
+
+	for _, lenient := range []bool{false, true} {
+		env := NewEnvironment()
+		env.Lenient = lenient
+
+		err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+			"count": {S: aws.String("10")},
+			":n":    {N: aws.String("5")},
+			":str":  {S: aws.String("10")},
+		})
+		if err != nil {
+			t.Fatalf("error adding attributes %#v", err)
+		}
+
+		for _, tt := range tests {
+			evaluated := testEval(t, tt.input, env)
+
+			switch {
+			case lenient && evaluated != tt.lenient:
+				t.Errorf("result has wrong value for %q in lenient mode. got=%v, want=%v", tt.input, evaluated, tt.lenient)
+			case !lenient && tt.strict != "" && evaluated.Inspect() != "ERROR: "+tt.strict:
+				t.Errorf("expected an error for %q in strict mode. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.strict)
+			case !lenient && tt.strict == "" && evaluated != tt.lenient:
+				t.Errorf("result has wrong value for %q in strict mode. got=%v, want=%v", tt.input, evaluated, tt.lenient)
+			}
+		}
+	}
+}
+
+func TestEvalBetweenBoundTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a BETWEEN :lo AND :hi", "true"},
+		{"a BETWEEN :txtLo AND :txtHi", "ERROR: mismatch type: BETWEEN operands must have the same type"},
+		{"missing BETWEEN :lo AND :hi", "false"},
+		{"a BETWEEN :lo AND :txtHi", "ERROR: mismatch type: BETWEEN operands must have the same type"},
+		{"a BETWEEN :txtLo AND :hi", "ERROR: mismatch type: BETWEEN operands must have the same type"},
+		{"missing BETWEEN :lo AND :txtHi", "ERROR: mismatch type: BETWEEN operands must have the same type"},
+		{"a BETWEEN :lo AND :missing", "false"},
+		{"a BETWEEN r.lo AND r.hi[0] AND a = :five", "true"},
+		{"attribute_exists(a) AND :five BETWEEN size(:txtLo) AND a", "true"},
+		{"a BETWEEN size(a) AND :hi", "ERROR: type not supported: size N"},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"a": {N: aws.String("5")},
+		"r": {M: map[string]*dynamodb.AttributeValue{
+			"lo": {N: aws.String("5")},
+			"hi": {L: []*dynamodb.AttributeValue{{N: aws.String("6")}}},
+		}},
+		":five":  {N: aws.String("5")},
+		":lo":    {N: aws.String("1")},
+		":hi":    {N: aws.String("10")},
+		":txtLo": {S: aws.String("a")},
+		":txtHi": {S: aws.String("z")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestEvalBetweenListElement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{"scores[0] BETWEEN :lo AND :hi", TRUE},
+		{"scores[1] BETWEEN :lo AND :hi", FALSE},
+		{"scores[5] BETWEEN :lo AND :hi", FALSE},
+		{"NOT scores[5] BETWEEN :lo AND :hi", TRUE},
+		{"games[1].score BETWEEN :lo AND :hi", TRUE},
+		{"games[2].score BETWEEN :lo AND :hi", FALSE},
+		{":five BETWEEN scores[0] AND scores[1]", FALSE},
+		{":five BETWEEN :lo AND scores[1]", TRUE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"scores": {L: []*dynamodb.AttributeValue{{N: aws.String("7")}, {N: aws.String("20")}}},
+		"games": {L: []*dynamodb.AttributeValue{
+			{M: map[string]*dynamodb.AttributeValue{"score": {N: aws.String("30")}}},
+			{M: map[string]*dynamodb.AttributeValue{"score": {N: aws.String("3")}}},
+		}},
+		":five": {N: aws.String("5")},
+		":lo":   {N: aws.String("1")},
+		":hi":   {N: aws.String("10")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalStringByteOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Object
+	}{
+		{":upperZ < :lowerA", TRUE},
+		{":lowerA > :upperZ", TRUE},
+		{":upperA = :lowerA", FALSE},
+		{":lowerZ < :accent", TRUE},
+		{":upperZ BETWEEN :upperA AND :lowerA", TRUE},
+		{":lowerZ BETWEEN :upperA AND :lowerA", FALSE},
+		{"begins_with(:lowerA, :upperA)", FALSE},
+		{"contains(:lowerA, :upperA)", FALSE},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		":upperA": {S: aws.String("A")},
+		":upperZ": {S: aws.String("Z")},
+		":lowerA": {S: aws.String("a")},
+		":lowerZ": {S: aws.String("z")},
+		// é is encoded as 0xC3 0xA9 and sorts after every ASCII character
+		":accent": {S: aws.String("é")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%v, want=%v", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestEvalIgnoresUnreferencedAttributes(t *testing.T) {
+	inputs := []string{
+		"a = :a",
+		"a <> :a",
+		"profile.name = :name",
+		"profile = :profile",
+		"attribute_exists(a) AND NOT attribute_exists(missing)",
+		"size(tags) = :one",
+		"tags = :tags OR contains(tags, :tag)",
+	}
+
+	base := map[string]*dynamodb.AttributeValue{
+		"a":        {N: aws.String("1")},
+		"profile":  {M: map[string]*dynamodb.AttributeValue{"name": {S: aws.String("john")}}},
+		"tags":     {SS: []*string{aws.String("x")}},
+		":a":       {N: aws.String("1")},
+		":name":    {S: aws.String("john")},
+		":profile": {M: map[string]*dynamodb.AttributeValue{"name": {S: aws.String("john")}}},
+		":one":     {N: aws.String("1")},
+		":tags":    {SS: []*string{aws.String("x")}},
+		":tag":     {S: aws.String("x")},
+	}
+
+	// the same item with extra attributes that are not referenced by the expressions
+	extended := map[string]*dynamodb.AttributeValue{
+		"b":     {N: aws.String("1")},
+		"other": {M: map[string]*dynamodb.AttributeValue{"name": {S: aws.String("doe")}}},
+		"list":  {L: []*dynamodb.AttributeValue{{N: aws.String("1")}}},
+	}
+
+	for k, v := range base {
+		extended[k] = v
+	}
+
+	for _, input := range inputs {
+		baseEnv := NewEnvironment()
+		if err := baseEnv.AddAttributes(base); err != nil {
+			t.Fatalf("error adding attributes %#v", err)
+		}
+
+		extendedEnv := NewEnvironment()
+		if err := extendedEnv.AddAttributes(extended); err != nil {
+			t.Fatalf("error adding attributes %#v", err)
+		}
+
+		expected := testEval(t, input, baseEnv)
+		actual := testEval(t, input, extendedEnv)
+
+		if isError(expected) {
+			t.Fatalf("unexpected error for %q: %s", input, expected.Inspect())
+		}
+
+		if actual != expected {
+			t.Errorf("unreferenced attributes changed the result of %q. got=%v, want=%v", input, actual, expected)
+		}
+	}
+}
+
+func TestEvalFunctionsByType(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"begins_with(s, :prefix)", "true"},
+		{"begins_with(s, :other)", "false"},
+		{"begins_with(b, :binPrefix)", "true"},
+		{"begins_with(b, :prefix)", "ERROR: invalid substr type S"},
+		{"begins_with(n, :prefix)", "ERROR: invalid type N"},
+		{"contains(s, :sub)", "true"},
+		{"contains(b, :binPrefix)", "true"},
+		{"contains(ss, :other)", "true"},
+		{"contains(ns, :one)", "true"},
+		{"contains(bs, :binPrefix)", "true"},
+		{"contains(l, :one)", "true"},
+		{"contains(l, :prefix)", "false"},
+		{"contains(n, :one)", "false"},
+		{"contains(s, :one)", "false"},
+		{"contains(ns, :prefix)", "false"},
+		{"contains(m, :prefix)", "false"},
+		{"contains(missing, :prefix)", "false"},
+		{"size(s) >= :five", "true"},
+		{"size(b) = :three", "true"},
+		{"size(ss) = :two", "true"},
+		{"size(ns) < :two", "false"},
+		{"size(bs) = :one", "true"},
+		{"size(l) = :three", "true"},
+		{"size(m) = :two", "true"},
+		{":five <= size(s) AND size(l) > :two", "true"},
+		{"size(n) > :one", "ERROR: type not supported: size N"},
+		{"size(t) > :one", "ERROR: type not supported: size BOOL"},
+	}
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"s":  {S: aws.String("hello world")},
+		"b":  {B: []byte("abc")},
+		"n":  {N: aws.String("1")},
+		"t":  {BOOL: aws.Bool(true)},
+		"ss": {SS: []*string{aws.String("hello"), aws.String("world")}},
+		"ns": {NS: []*string{aws.String("1"), aws.String("3"), aws.String("1")}},
+		"bs": {BS: [][]byte{[]byte("ab")}},
+		"l":  {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, {S: aws.String("x")}, {NULL: aws.Bool(true)}}},
+		"m": {M: map[string]*dynamodb.AttributeValue{
+			"hello": {S: aws.String("hello")},
+			"inner": {M: map[string]*dynamodb.AttributeValue{"hello": {S: aws.String("hello")}}},
+		}},
+		":prefix":    {S: aws.String("hello")},
+		":other":     {S: aws.String("world")},
+		":sub":       {S: aws.String("o w")},
+		":binPrefix": {B: []byte("ab")},
+		":one":       {N: aws.String("1")},
+		":two":       {N: aws.String("2")},
+		":three":     {N: aws.String("3")},
+		":five":      {N: aws.String("5")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input, env)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("result has wrong value for %q. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestEvalAttributeType(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"s":    {S: aws.String("x")},
+		"n":    {N: aws.String("1")},
+		"b":    {B: []byte("x")},
+		"bool": {BOOL: aws.Bool(false)},
+		"null": {NULL: aws.Bool(true)},
+		"l":    {L: []*dynamodb.AttributeValue{{S: aws.String("x")}}},
+		"m":    {M: map[string]*dynamodb.AttributeValue{"k": {S: aws.String("x")}}},
+		"ss":   {SS: []*string{aws.String("x")}},
+		"ns":   {NS: []*string{aws.String("1")}},
+		"bs":   {BS: [][]byte{[]byte("x")}},
+	}
+
+	codes := map[string]string{
+		"s": "S", "n": "N", "b": "B", "bool": "BOOL", "null": "NULL",
+		"l": "L", "m": "M", "ss": "SS", "ns": "NS", "bs": "BS",
+	}
+
+	env := NewEnvironment()
+	if err := env.AddAttributes(item); err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	for _, code := range codes {
+		if err := env.AddAttributes(map[string]*dynamodb.AttributeValue{":" + code: {S: aws.String(code)}}); err != nil {
+			t.Fatalf("error adding attributes %#v", err)
+		}
+	}
+
+	for name, code := range codes {
+		for _, other := range codes {
+			input := fmt.Sprintf("attribute_type(%s, :%s)", name, other)
+
+			expected := nativeBoolToBooleanObject(code == other)
+			if evaluated := testEval(t, input, env); evaluated != expected {
+				t.Errorf("result has wrong value for %q. got=%v, want=%v", input, evaluated, expected)
+			}
+		}
+
+		// the missing attributes have no type
+		input := fmt.Sprintf("attribute_type(missing, :%s)", code)
+		if evaluated := testEval(t, input, env); evaluated != FALSE {
+			t.Errorf("result has wrong value for %q. got=%v, want=false", input, evaluated)
+		}
+	}
+
+	if err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		":lower":  {S: aws.String("s")},
+		":number": {N: aws.String("1")},
+	}); err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"attribute_type(s, :lower)", "invalid type s, the type must be one of S, N, B, BOOL, NULL, L, M, SS, NS or BS"},
+		{"attribute_type(missing, :lower)", "invalid type s, the type must be one of S, N, B, BOOL, NULL, L, M, SS, NS or BS"},
+		{"attribute_type(n, :number)", "invalid type N, the type must be a string"},
+	}
+
+	for _, tt := range errorTests {
+		errObj, ok := testEval(t, tt.input, env).(*Error)
+		if !ok {
+			t.Fatalf("expected an error for %q", tt.input)
+		}
+
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expected, errObj.Message)
+		}
+	}
+}
+
 func testEval(t *testing.T, input string, env *Environment) Object {
 	l := NewLexer(input)
 	p := NewParser(l)
@@ -254,14 +1462,22 @@ func TestErrorHandling(t *testing.T) {
 			"size(:a)",
 			"type not supported: size BOOL",
 		},
-		{
-			"undefined(:a)",
-			"function not found: undefined",
-		},
 		{
 			"NOT :nil",
 			"unknown operator: NOT NULL",
 		},
+		{
+			":nil > :x",
+			"type mismatch: NULL > N",
+		},
+		{
+			":x <= :nil",
+			"type mismatch: N <= NULL",
+		},
+		{
+			":nil < :nil",
+			"unknown operator: NULL < NULL",
+		},
 		{
 			"NOT :notfound",
 			"unknown operator: NOT NULL",
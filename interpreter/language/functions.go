@@ -7,7 +7,9 @@ import (
 
 // Function represents a function in the dynamodb expression
 type Function struct {
-	Name  string
+	Name string
+	// Arity number of arguments of the function
+	Arity int
 	Value func(...Object) Object
 }
 
@@ -22,60 +24,95 @@ func (fn *Function) Type() ObjectType {
 }
 
 var (
+	// functions supported by DynamoDB, the parser validates the calls with their Arity,
+	// list_append and if_not_exists are only valid in UpdateExpression
 	functions = map[string]*Function{
 		"attribute_exists": &Function{
 			Name:  "attribute_exists",
+			Arity: 1,
 			Value: attributeExists,
 		},
 		"attribute_not_exists": &Function{
 			Name:  "attribute_not_exists",
+			Arity: 1,
 			Value: attributeNotExists,
 		},
 		"attribute_type": &Function{
 			Name:  "attribute_type",
+			Arity: 2,
 			Value: attributeType,
 		},
 		"begins_with": &Function{
 			Name:  "begins_with",
+			Arity: 2,
 			Value: beginsWith,
 		},
 		"contains": &Function{
 			Name:  "contains",
+			Arity: 2,
 			Value: contains,
 		},
 		"size": &Function{
 			Name:  "size",
+			Arity: 1,
 			Value: objectSize,
 		},
+		"if_not_exists": &Function{
+			Name:  "if_not_exists",
+			Arity: 2,
+			Value: ifNotExists,
+		},
+		"list_append": &Function{
+			Name:  "list_append",
+			Arity: 2,
+			Value: listAppend,
+		},
 	}
 )
 
+// LookupFunction returns the number of arguments of the DynamoDB function, ok is false
+// when the function is not supported
+func LookupFunction(name string) (arity int, ok bool) {
+	fn, ok := functions[name]
+	if !ok {
+		return 0, false
+	}
+
+	return fn.Arity, true
+}
+
+// attributeExists NULL typed attributes exist, only the undefined ones are missing
 func attributeExists(args ...Object) Object {
 	path := args[0]
 
-	return nativeBoolToBooleanObject(path.Type() != ObjectTypeNull)
+	return nativeBoolToBooleanObject(!isUndefined(path))
 }
 
 func attributeNotExists(args ...Object) Object {
 	path := args[0]
 
-	return nativeBoolToBooleanObject(path.Type() == ObjectTypeNull)
+	return nativeBoolToBooleanObject(isUndefined(path))
 }
 
+// attributeType the missing attributes do not have any type, but the type must be valid
 func attributeType(args ...Object) Object {
 	path := args[0]
 	typ := args[1]
 
-	if typ.Type() == ObjectTypeString {
-		strObj, _ := typ.(*String)
-		if !dynamodbTypes[ObjectType(strObj.Value)] {
-			return newError("invalid type %s", strObj.Value)
-		}
+	if typ.Type() != ObjectTypeString {
+		return newError("invalid type %s, the type must be a string", typ.Type())
+	}
 
-		return nativeBoolToBooleanObject(path.Type() == ObjectType(strObj.Value))
+	strObj, _ := typ.(*String)
+	if !dynamodbTypes[ObjectType(strObj.Value)] {
+		return newError("invalid type %s, the type must be one of S, N, B, BOOL, NULL, L, M, SS, NS or BS", strObj.Value)
 	}
 
-	return newError("invalid type %s", typ.Type())
+	if isUndefined(path) {
+		return FALSE
+	}
+
+	return nativeBoolToBooleanObject(path.Type() == ObjectType(strObj.Value))
 }
 
 func beginsWith(args ...Object) Object {
@@ -104,17 +141,14 @@ func beginsWith(args ...Object) Object {
 	return newError("invalid type %s", path.Type())
 }
 
+// contains is false when the path can not contain the operand, e.g. a number path
 func contains(args ...Object) Object {
 	path := args[0]
 	operand := args[1]
 
 	container, ok := path.(ContainerObject)
-	if !ok {
-		return newError("contains is not supported for path=%s", path.Type())
-	}
-
-	if !container.CanContain(operand.Type()) {
-		return newError("contains is not supported for path=%s operand=%s", path.Type(), operand.Type())
+	if !ok || !container.CanContain(operand.Type()) {
+		return FALSE
 	}
 
 	return nativeBoolToBooleanObject(container.Contains(operand))
@@ -132,7 +166,61 @@ func objectSize(args ...Object) Object {
 		bin, _ := path.(*Binary)
 
 		return &Number{Value: float64(len(bin.Value))}
+	case ObjectTypeStringSet:
+		set, _ := path.(*StringSet)
+
+		return &Number{Value: float64(len(set.Value))}
+	case ObjectTypeNumberSet:
+		set, _ := path.(*NumberSet)
+
+		return &Number{Value: float64(len(set.Value))}
+	case ObjectTypeBinarySet:
+		set, _ := path.(*BinarySet)
+
+		return &Number{Value: float64(len(set.Value))}
+	case ObjectTypeList:
+		list, _ := path.(*List)
+
+		return &Number{Value: float64(len(list.Value))}
+	case ObjectTypeMap:
+		m, _ := path.(*Map)
+
+		return &Number{Value: float64(len(m.Value))}
 	}
 
 	return newError("type not supported: size %s", path.Type())
 }
+
+// ifNotExists returns the value of the path or the fallback when the path is missing
+func ifNotExists(args ...Object) Object {
+	path := args[0]
+	fallback := args[1]
+
+	if isUndefined(path) {
+		return fallback
+	}
+
+	return path
+}
+
+// listAppend returns a new list with the elements of both lists
+func listAppend(args ...Object) Object {
+	for _, arg := range args {
+		if isUndefined(arg) {
+			return newError("the provided expression refers to an attribute that does not exist in the item")
+		}
+	}
+
+	left, leftOk := args[0].(*List)
+	right, rightOk := args[1].(*List)
+
+	if !leftOk || !rightOk {
+		return newError("an operand in the update expression has an incorrect data type: list_append(%s, %s)", args[0].Type(), args[1].Type())
+	}
+
+	value := make([]Object, 0, len(left.Value)+len(right.Value))
+	value = append(value, left.Value...)
+	value = append(value, right.Value...)
+
+	return &List{Value: value}
+}
@@ -15,6 +15,33 @@ func TestFunctionInspect(t *testing.T) {
 	}
 }
 
+func TestFunctionArity(t *testing.T) {
+	expected := map[string]int{
+		"attribute_exists":     1,
+		"attribute_not_exists": 1,
+		"attribute_type":       2,
+		"begins_with":          2,
+		"contains":             2,
+		"size":                 1,
+		"list_append":          2,
+		"if_not_exists":        2,
+	}
+
+	if len(functions) != len(expected) {
+		t.Errorf("wrong number of functions. expected=%d, got=%d", len(expected), len(functions))
+	}
+
+	for name, arity := range expected {
+		if actual, ok := LookupFunction(name); !ok || actual != arity {
+			t.Errorf("wrong arity of %s. expected=%d, got=%d", name, arity, actual)
+		}
+	}
+
+	if _, ok := LookupFunction("lower"); ok {
+		t.Errorf("unexpected function lower")
+	}
+}
+
 func TestAttributeExists(t *testing.T) {
 	str := &String{Value: "hello"}
 
@@ -43,6 +70,22 @@ func TestAttributeNotExists(t *testing.T) {
 	}
 }
 
+func TestAttributeExistsWithNullType(t *testing.T) {
+	null := &Null{}
+
+	if attributeExists(null) != TRUE {
+		t.Fatal("NULL typed attributes should exist")
+	}
+
+	if attributeNotExists(null) != FALSE {
+		t.Fatal("NULL typed attributes should exist")
+	}
+
+	if attributeExists(NULL) != FALSE {
+		t.Fatal("undefined attributes should not exist")
+	}
+}
+
 func TestAttributeType(t *testing.T) {
 	str := &String{Value: "hello"}
 	expected := &String{Value: "S"}
@@ -55,7 +98,7 @@ func TestAttributeType(t *testing.T) {
 	expected = &String{Value: "TYPE"}
 	isExpectedType = attributeType(str, expected)
 
-	if isExpectedType.Type() != ObjectTypeError || isExpectedType.Inspect() != "ERROR: invalid type TYPE" {
+	if isExpectedType.Type() != ObjectTypeError || isExpectedType.Inspect() != "ERROR: invalid type TYPE, the type must be one of S, N, B, BOOL, NULL, L, M, SS, NS or BS" {
 		t.Fatalf("expect invalid type error, got=%s %s", isExpectedType.Type(), isExpectedType.Inspect())
 	}
 }
@@ -96,6 +139,29 @@ func TestBeginsWithFailure(t *testing.T) {
 	}
 }
 
+func TestBeginsWithBinaryLengths(t *testing.T) {
+	data := &Binary{Value: []byte{0xde, 0xad, 0xbe, 0xef}}
+
+	tests := []struct {
+		name     string
+		prefix   []byte
+		expected Object
+	}{
+		{"matching prefix", []byte{0xde, 0xad}, TRUE},
+		{"same value", []byte{0xde, 0xad, 0xbe, 0xef}, TRUE},
+		{"empty prefix", []byte{}, TRUE},
+		{"non matching prefix", []byte{0xde, 0xae}, FALSE},
+		{"prefix longer than the attribute", []byte{0xde, 0xad, 0xbe, 0xef, 0x00}, FALSE},
+	}
+
+	for _, tt := range tests {
+		begins := beginsWith(data, &Binary{Value: tt.prefix})
+		if begins != tt.expected {
+			t.Errorf("%s: expected=%s, got=%s", tt.name, tt.expected.Inspect(), begins.Inspect())
+		}
+	}
+}
+
 func TestBeginsWithError(t *testing.T) {
 	str := &String{Value: "Beto Gomez"}
 	expectedBinary := &Binary{Value: []byte{'j', 'o'}}
@@ -149,20 +215,54 @@ func TestContainsWithFailure(t *testing.T) {
 	}
 }
 
-func TestContainsWithError(t *testing.T) {
+func TestContainsMultibyte(t *testing.T) {
+	tests := []struct {
+		str      string
+		sub      string
+		expected Object
+	}{
+		{"José Gómez", "Gó", TRUE},
+		{"José Gómez", "é G", TRUE},
+		{"José Gómez", "è", FALSE},
+		{"日本語のテキスト", "本語", TRUE},
+		{"日本語のテキスト", "語テ", FALSE},
+		{"emoji 🙂 text", "🙂", TRUE},
+		{"emoji 🙂 text", "🙃", FALSE},
+		{"café", "cafe", FALSE},
+	}
+
+	for _, tt := range tests {
+		contained := contains(&String{Value: tt.str}, &String{Value: tt.sub})
+		if contained != tt.expected {
+			t.Errorf("wrong result for contains(%q, %q). expected=%s, got=%s", tt.str, tt.sub, tt.expected.Inspect(), contained.Inspect())
+		}
+	}
+
+	set := &StringSet{Value: map[string]bool{"José": true, "日本": true}}
+
+	if contains(set, &String{Value: "日本"}) != TRUE {
+		t.Errorf("expected the string set to contain the multibyte element")
+	}
+
+	if contains(set, &String{Value: "Jos"}) != FALSE {
+		t.Errorf("expected the string set to match only whole elements")
+	}
+}
+
+func TestContainsTypeMismatch(t *testing.T) {
 	str := &String{Value: "Beto Gomez"}
 	expectedBinary := &Binary{Value: []byte{'j', 'o'}}
 
 	contained := contains(str, expectedBinary)
-	if contained.Type() != ObjectTypeError || contained.Inspect() != "ERROR: contains is not supported for path=S operand=B" {
-		t.Fatalf("expect invalid type error, got=%s %q", contained.Type(), contained.Inspect())
+	if contained != FALSE {
+		t.Fatalf("expect false for a binary operand in a string, got=%s %q", contained.Type(), contained.Inspect())
 	}
 
 	num := &Number{Value: 5}
 	contained = contains(num, expectedBinary)
 
-	if contained.Type() != ObjectTypeError || contained.Inspect() != "ERROR: contains is not supported for path=N" {
-		t.Fatalf("expect invalid type error, got=%s %q", contained.Type(), contained.Inspect())
+	if contained != FALSE {
+		t.Fatalf("expect false for a number path, got=%s %q", contained.Type(), contained.Inspect())
 	}
 }
 
@@ -187,6 +287,43 @@ func TestObjectSize(t *testing.T) {
 		t.Fatalf("error expected: %s", size.Inspect())
 	}
 }
+
+func TestObjectSizeOfMaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        *Map
+		expected string
+	}{
+		{"empty", &Map{Value: map[string]Object{}}, "0.000000"},
+		{
+			"flat",
+			&Map{Value: map[string]Object{
+				"a": &String{Value: "x"},
+				"b": &Number{Value: 1},
+				"c": TRUE,
+			}},
+			"3.000000",
+		},
+		{
+			"nested",
+			&Map{Value: map[string]Object{
+				"a": &Map{Value: map[string]Object{
+					"b": &Map{Value: map[string]Object{"c": &String{Value: "x"}, "d": &String{Value: "y"}}},
+					"e": &Number{Value: 1},
+				}},
+				"f": &List{Value: []Object{&Number{Value: 1}, &Number{Value: 2}}},
+			}},
+			"2.000000",
+		},
+	}
+
+	for _, tt := range tests {
+		size := objectSize(tt.m)
+		if size.Inspect() != tt.expected {
+			t.Errorf("wrong size for the %s map. expected=%s, got=%s", tt.name, tt.expected, size.Inspect())
+		}
+	}
+}
 func BenchmarkFunctionInspect(b *testing.B) {
 	fn := Function{
 		Name:  "attribute_exists",
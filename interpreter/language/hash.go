@@ -0,0 +1,81 @@
+package language
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+)
+
+// Hash returns a stable hash of the syntax tree, it is the same for the trees with the same
+// structure regardless of the spacing and the parentheses of the source expression, e.g.
+// a = :x AND b = :y and ((a=:x) AND (b=:y)). It can be used as the key of an expression cache
+func Hash(n Node) uint64 {
+	h := fnv.New64a()
+
+	hashNode(h, n)
+
+	return h.Sum64()
+}
+
+// hashNode writes the nodes in pre-order with the number of children, so the
+// encoding of different trees is always different
+func hashNode(h hash.Hash64, n Node) {
+	if isNilNode(n) {
+		hashString(h, "nil")
+
+		return
+	}
+
+	if shared, ok := n.(*sharedExpression); ok {
+		hashNode(h, shared.Expression)
+
+		return
+	}
+
+	if accessor, ok := n.(*attributeAccessor); ok {
+		hashNode(h, accessor.Identifier)
+
+		return
+	}
+
+	hashString(h, fmt.Sprintf("%T", n))
+
+	switch node := n.(type) {
+	case *Identifier:
+		hashString(h, node.Value)
+	case *BoolLiteral:
+		hashString(h, fmt.Sprint(node.Value))
+	case *NumberLiteral:
+		hashString(h, fmt.Sprint(node.Value))
+	case *StringLiteral:
+		hashString(h, node.Value)
+	case *PrefixExpression:
+		hashString(h, node.Operator)
+	case *InfixExpression:
+		hashString(h, node.Operator)
+	case *IndexExpression:
+		hashString(h, string(node.Token.Type))
+		hashString(h, fmt.Sprint(node.Index))
+	case *UpdateClause:
+		hashString(h, string(node.Token.Type))
+	case *UpdateAction:
+		hashString(h, string(node.Token.Type))
+	}
+
+	nodes := children(n)
+
+	hashString(h, fmt.Sprint(len(nodes)))
+
+	for _, child := range nodes {
+		hashNode(h, child)
+	}
+}
+
+// hashString writes the length before the value to keep the values delimited
+func hashString(h hash.Hash64, str string) {
+	var size [binary.MaxVarintLen64]byte
+
+	_, _ = h.Write(size[:binary.PutUvarint(size[:], uint64(len(str)))])
+	_, _ = h.Write([]byte(str))
+}
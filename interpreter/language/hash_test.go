@@ -0,0 +1,85 @@
+package language
+
+import (
+	"testing"
+)
+
+func TestHashEqualTrees(t *testing.T) {
+	tests := []struct {
+		a string
+		b string
+	}{
+		{"a = :x", "a=:x"},
+		{"a = :x AND b = :y", "((a = :x) AND (b = :y))"},
+		{"NOT a IN (:x, :y)", "NOT (a IN (:x,:y))"},
+		{"size(a.b[0]) > :n", "size( a.b[0] ) > :n"},
+		{"a BETWEEN :x AND :y OR attribute_exists(#n)", "(a BETWEEN :x AND :y) OR (attribute_exists(#n))"},
+	}
+
+	for _, tt := range tests {
+		a := Hash(testParse(t, tt.a))
+		b := Hash(testParse(t, tt.b))
+
+		if a != b {
+			t.Errorf("expected the same hash for %q and %q. got=%d and %d", tt.a, tt.b, a, b)
+		}
+	}
+}
+
+func TestHashDifferentTrees(t *testing.T) {
+	inputs := []string{
+		"a = :x",
+		"a <> :x",
+		"a = :y",
+		":x = a",
+		"b = :x",
+		"a.b = :x",
+		"a[0] = :x",
+		"a[1] = :x",
+		"a = :x AND b = :y",
+		"a = :x OR b = :y",
+		"a = :x AND (b = :y OR c = :z)",
+		"(a = :x AND b = :y) OR c = :z",
+		"NOT a = :x",
+		"a IN (:x, :y)",
+		"a IN (:y, :x)",
+		"a BETWEEN :x AND :y",
+		"begins_with(a, :x)",
+		"contains(a, :x)",
+		"attribute_exists(a)",
+	}
+
+	hashes := map[uint64]string{}
+
+	for _, input := range inputs {
+		h := Hash(testParse(t, input))
+
+		if other, ok := hashes[h]; ok {
+			t.Errorf("expected different hashes for %q and %q", input, other)
+		}
+
+		hashes[h] = input
+	}
+}
+
+func TestHashUpdateExpressions(t *testing.T) {
+	a := Hash(testParseUpdate(t, "SET a = :a, b = :b REMOVE c"))
+	b := Hash(testParseUpdate(t, "REMOVE c SET a=:a,b=:b"))
+	c := Hash(testParseUpdate(t, "SET a = :a REMOVE b, c"))
+
+	if a != b {
+		t.Errorf("expected the same hash for the reordered clauses. got=%d and %d", a, b)
+	}
+
+	if a == c {
+		t.Errorf("expected different hashes for different update expressions")
+	}
+}
+
+func TestHashIgnoresOptimizations(t *testing.T) {
+	program := testParse(t, "(a = :x AND b = :y) OR (a = :x AND c = :z)")
+
+	if Hash(program) != Hash(EliminateCommonSubexpressions(program)) {
+		t.Errorf("expected the same hash after the common subexpression elimination")
+	}
+}
@@ -0,0 +1,309 @@
+package language
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// SelectivityClass describes how the sort key condition restricts the items of a partition
+type SelectivityClass string
+
+const (
+	// SelectivityNone the key condition does not use the sort key
+	SelectivityNone SelectivityClass = "none"
+	// SelectivityExact the sort key must be equal to a value, e.g. sk = :v
+	SelectivityExact SelectivityClass = "exact"
+	// SelectivityPrefix the sort key must start with a value, e.g. begins_with(sk, :v)
+	SelectivityPrefix SelectivityClass = "prefix"
+	// SelectivityRange the sort key must be in a range, e.g. sk > :v or sk BETWEEN :a AND :b
+	SelectivityRange SelectivityClass = "range"
+)
+
+// KeyConditionSelectivity classifies the clause of the key condition that uses the
+// sort key, the name must be the one used in the expression, e.g. #sk
+func KeyConditionSelectivity(expr *DynamoExpression, sortKey string) SelectivityClass {
+	stmt, ok := expr.Statement.(*ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return SelectivityNone
+	}
+
+	for _, term := range Conjuncts(stmt.Expression) {
+		class := sortKeySelectivity(term, sortKey)
+		if class != SelectivityNone {
+			return class
+		}
+	}
+
+	return SelectivityNone
+}
+
+func sortKeySelectivity(term Expression, sortKey string) SelectivityClass {
+	switch node := term.(type) {
+	case *InfixExpression:
+		if !isIdentifierNamed(node.Left, sortKey) && !isIdentifierNamed(node.Right, sortKey) {
+			return SelectivityNone
+		}
+
+		switch node.Operator {
+		case EQ:
+			return SelectivityExact
+		case LT, LTE, GT, GTE:
+			return SelectivityRange
+		}
+	case *BetweenExpression:
+		if isIdentifierNamed(node.Left, sortKey) {
+			return SelectivityRange
+		}
+	case *CallExpression:
+		if isIdentifierNamed(node.Function, "begins_with") && len(node.Arguments) == 2 && isIdentifierNamed(node.Arguments[0], sortKey) {
+			return SelectivityPrefix
+		}
+	}
+
+	return SelectivityNone
+}
+
+func isIdentifierNamed(exp Expression, name string) bool {
+	identifier, ok := exp.(*Identifier)
+
+	return ok && identifier.Value == name
+}
+
+// bound limit of the values of an attribute
+type bound struct {
+	value     *dynamodb.AttributeValue
+	obj       Object
+	inclusive bool
+}
+
+// BoundsFor returns the tightest lower and upper bounds of the attribute found in the
+// comparisons of the AND terms, e.g. a > :x AND a <= :y. The terms comparing with values
+// of different types are ignored, ok is false when no bound is found
+func BoundsFor(expr *DynamoExpression, attr string, values map[string]*dynamodb.AttributeValue) (lower, upper *dynamodb.AttributeValue, lowerInclusive, upperInclusive bool, ok bool) {
+	stmt, isStmt := expr.Statement.(*ExpressionStatement)
+	if !isStmt || stmt.Expression == nil {
+		return nil, nil, false, false, false
+	}
+
+	var low, up *bound
+
+	for _, term := range Conjuncts(stmt.Expression) {
+		termLow, termUp := termBounds(term, attr, values)
+
+		low = tighterBound(low, termLow, 1)
+		up = tighterBound(up, termUp, -1)
+	}
+
+	if low != nil {
+		lower, lowerInclusive = low.value, low.inclusive
+	}
+
+	if up != nil {
+		upper, upperInclusive = up.value, up.inclusive
+	}
+
+	return lower, upper, lowerInclusive, upperInclusive, low != nil || up != nil
+}
+
+// termBounds returns the bounds given by the term, nil when the term does not limit the attribute
+func termBounds(term Expression, attr string, values map[string]*dynamodb.AttributeValue) (lower, upper *bound) {
+	switch node := term.(type) {
+	case *InfixExpression:
+		operator := node.Operator
+		operand := node.Right
+
+		if !isIdentifierNamed(node.Left, attr) {
+			if !isIdentifierNamed(node.Right, attr) {
+				return nil, nil
+			}
+
+			// :x < a is the same as a > :x
+			operator = invertedComparators[operator]
+			operand = node.Left
+		}
+
+		b := placeholderBound(operand, values, operator == EQ || operator == LTE || operator == GTE)
+		if b == nil {
+			return nil, nil
+		}
+
+		switch operator {
+		case EQ:
+			return b, b
+		case GT, GTE:
+			return b, nil
+		case LT, LTE:
+			return nil, b
+		}
+	case *BetweenExpression:
+		if !isIdentifierNamed(node.Left, attr) {
+			return nil, nil
+		}
+
+		return placeholderBound(node.Range[0], values, true), placeholderBound(node.Range[1], values, true)
+	}
+
+	return nil, nil
+}
+
+var invertedComparators = map[string]string{
+	EQ:  EQ,
+	LT:  GT,
+	LTE: GTE,
+	GT:  LT,
+	GTE: LTE,
+}
+
+func placeholderBound(operand Expression, values map[string]*dynamodb.AttributeValue, inclusive bool) *bound {
+	identifier, ok := operand.(*Identifier)
+	if !ok {
+		return nil
+	}
+
+	val, ok := values[identifier.Value]
+	if !ok || val == nil {
+		return nil
+	}
+
+	obj, err := MapToObject(val)
+	if err != nil || !comparableTypes[obj.Type()] {
+		return nil
+	}
+
+	return &bound{value: val, obj: obj, inclusive: inclusive}
+}
+
+// tighterBound returns the most restrictive bound, direction is 1 for the lower bounds
+// and -1 for the upper bounds
+func tighterBound(current, candidate *bound, direction int) *bound {
+	if candidate == nil {
+		return current
+	}
+
+	if current == nil {
+		return candidate
+	}
+
+	cmp, ok := compareObjects(candidate.obj, current.obj)
+	if !ok {
+		return current
+	}
+
+	switch cmp * direction {
+	case 1:
+		return candidate
+	case -1:
+		return current
+	}
+
+	return &bound{value: current.value, obj: current.obj, inclusive: current.inclusive && candidate.inclusive}
+}
+
+// compareObjects compares two values of the same comparable type
+func compareObjects(left, right Object) (int, bool) {
+	if !comparableTypes[left.Type()] || left.Type() != right.Type() {
+		return 0, false
+	}
+
+	if evalInfixExpression(LT, left, right) == TRUE {
+		return -1, true
+	}
+
+	if evalInfixExpression(EQ, left, right) == TRUE {
+		return 0, true
+	}
+
+	return 1, true
+}
+
+// QueryKeyCondition key condition in the form used by the KeyConditions parameter of the SDK
+type QueryKeyCondition struct {
+	PartitionKey   string
+	PartitionValue *dynamodb.AttributeValue
+	SortKey        string
+	// SortCondition nil when the key condition does not use the sort key
+	SortCondition *dynamodb.Condition
+}
+
+var keyConditionOperators = map[string]string{
+	EQ:  dynamodb.ComparisonOperatorEq,
+	LT:  dynamodb.ComparisonOperatorLt,
+	LTE: dynamodb.ComparisonOperatorLe,
+	GT:  dynamodb.ComparisonOperatorGt,
+	GTE: dynamodb.ComparisonOperatorGe,
+}
+
+// ToQueryKeyCondition converts the key condition expression to its SDK representation, the
+// names and values are the expression attribute names and values of the query
+func ToQueryKeyCondition(expr *DynamoExpression, pk, sk string, names map[string]*string, values map[string]*dynamodb.AttributeValue) (*QueryKeyCondition, error) {
+	stmt, ok := expr.Statement.(*ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return nil, errors.New("empty key condition expression")
+	}
+
+	env := NewEnvironment()
+	env.Aliases = nameAliases(names)
+
+	cond := &QueryKeyCondition{PartitionKey: pk, SortKey: sk}
+
+	for _, term := range Conjuncts(stmt.Expression) {
+		attr, condition, err := keyConditionTerm(term, env, values)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case attr == pk && cond.PartitionValue == nil && *condition.ComparisonOperator == dynamodb.ComparisonOperatorEq:
+			cond.PartitionValue = condition.AttributeValueList[0]
+		case attr == sk && sk != "" && cond.SortCondition == nil:
+			cond.SortCondition = condition
+		default:
+			return nil, fmt.Errorf("invalid key condition: %s", term.String())
+		}
+	}
+
+	if cond.PartitionValue == nil {
+		return nil, fmt.Errorf("the key condition must compare the partition key %s with =", pk)
+	}
+
+	return cond, nil
+}
+
+func keyConditionTerm(term Expression, env *Environment, values map[string]*dynamodb.AttributeValue) (string, *dynamodb.Condition, error) {
+	var (
+		path     Expression
+		operator string
+		operands []Expression
+	)
+
+	switch node := term.(type) {
+	case *InfixExpression:
+		path, operator, operands = node.Left, keyConditionOperators[node.Operator], []Expression{node.Right}
+	case *BetweenExpression:
+		path, operator, operands = node.Left, dynamodb.ComparisonOperatorBetween, node.Range[:]
+	case *CallExpression:
+		if isIdentifierNamed(node.Function, "begins_with") && len(node.Arguments) == 2 {
+			path, operator, operands = node.Arguments[0], dynamodb.ComparisonOperatorBeginsWith, node.Arguments[1:]
+		}
+	}
+
+	identifier, ok := path.(*Identifier)
+	if !ok || operator == "" {
+		return "", nil, fmt.Errorf("invalid key condition: %s", term.String())
+	}
+
+	condition := &dynamodb.Condition{ComparisonOperator: &operator}
+
+	for _, operand := range operands {
+		val, ok := values[operand.String()]
+		if !ok {
+			return "", nil, fmt.Errorf("an expression attribute value used in the key condition is not defined: %s", operand.String())
+		}
+
+		condition.AttributeValueList = append(condition.AttributeValueList, val)
+	}
+
+	return env.ResolveName(identifier.Value), condition, nil
+}
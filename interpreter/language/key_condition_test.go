@@ -0,0 +1,223 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestKeyConditionSelectivity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected SelectivityClass
+	}{
+		{"pk = :pk", SelectivityNone},
+		{"pk = :pk AND sk = :sk", SelectivityExact},
+		{"sk = :sk AND pk = :pk", SelectivityExact},
+		{"pk = :pk AND begins_with(sk, :prefix)", SelectivityPrefix},
+		{"pk = :pk AND sk < :sk", SelectivityRange},
+		{"pk = :pk AND sk <= :sk", SelectivityRange},
+		{"pk = :pk AND sk > :sk", SelectivityRange},
+		{"pk = :pk AND sk >= :sk", SelectivityRange},
+		{"pk = :pk AND sk BETWEEN :a AND :b", SelectivityRange},
+		{"pk = :pk AND begins_with(other, :prefix)", SelectivityNone},
+	}
+
+	for _, tt := range tests {
+		program := testParse(t, tt.input)
+
+		actual := KeyConditionSelectivity(program, "sk")
+		if actual != tt.expected {
+			t.Errorf("wrong selectivity for %q. expected=%s, got=%s", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestBoundsFor(t *testing.T) {
+	values := map[string]*dynamodb.AttributeValue{
+		":one":   {N: aws.String("1")},
+		":five":  {N: aws.String("5")},
+		":ten":   {N: aws.String("10")},
+		":txt":   {S: aws.String("txt")},
+		":other": {BOOL: aws.Bool(true)},
+	}
+
+	tests := []struct {
+		input          string
+		lower          string
+		upper          string
+		lowerInclusive bool
+		upperInclusive bool
+		ok             bool
+	}{
+		{"a > :one AND a <= :ten", "1", "10", false, true, true},
+		{"a > :one AND a >= :five AND a < :ten AND a <= :ten", "5", "10", true, false, true},
+		{"a >= :five AND a > :five", "5", "", false, false, true},
+		{"a BETWEEN :one AND :ten AND a < :five", "1", "5", true, false, true},
+		{":five > a AND :one <= a", "1", "5", true, false, true},
+		{"a = :five AND b > :ten", "5", "5", true, true, true},
+		{"a > :one AND a > :txt", "1", "", false, false, true},
+		{"b > :one AND a <> :five", "", "", false, false, false},
+		{"a > :one OR a < :ten", "", "", false, false, false},
+		{"a > :other AND a > :missing", "", "", false, false, false},
+	}
+
+	for _, tt := range tests {
+		program := testParse(t, tt.input)
+
+		lower, upper, lowerInclusive, upperInclusive, ok := BoundsFor(program, "a", values)
+		if ok != tt.ok {
+			t.Errorf("wrong ok for %q. expected=%t, got=%t", tt.input, tt.ok, ok)
+		}
+
+		if actual := boundString(lower); actual != tt.lower || lowerInclusive != tt.lowerInclusive {
+			t.Errorf("wrong lower bound for %q. expected=%q(%t), got=%q(%t)", tt.input, tt.lower, tt.lowerInclusive, actual, lowerInclusive)
+		}
+
+		if actual := boundString(upper); actual != tt.upper || upperInclusive != tt.upperInclusive {
+			t.Errorf("wrong upper bound for %q. expected=%q(%t), got=%q(%t)", tt.input, tt.upper, tt.upperInclusive, actual, upperInclusive)
+		}
+	}
+}
+
+func boundString(val *dynamodb.AttributeValue) string {
+	if val == nil {
+		return ""
+	}
+
+	if val.N != nil {
+		return *val.N
+	}
+
+	return aws.StringValue(val.S)
+}
+
+func TestToQueryKeyCondition(t *testing.T) {
+	values := map[string]*dynamodb.AttributeValue{
+		":pk":     {S: aws.String("user")},
+		":sk":     {N: aws.String("1")},
+		":hi":     {N: aws.String("5")},
+		":prefix": {S: aws.String("2021-")},
+	}
+
+	names := map[string]*string{
+		"#pk": aws.String("pk"),
+		"#sk": aws.String("sk"),
+	}
+
+	tests := []struct {
+		input    string
+		operator string
+		sortList []string
+	}{
+		{"pk = :pk", "", nil},
+		{"#pk = :pk", "", nil},
+		{"pk = :pk AND sk = :sk", dynamodb.ComparisonOperatorEq, []string{"1"}},
+		{"sk < :sk AND pk = :pk", dynamodb.ComparisonOperatorLt, []string{"1"}},
+		{"pk = :pk AND #sk <= :sk", dynamodb.ComparisonOperatorLe, []string{"1"}},
+		{"pk = :pk AND sk > :sk", dynamodb.ComparisonOperatorGt, []string{"1"}},
+		{"pk = :pk AND sk >= :sk", dynamodb.ComparisonOperatorGe, []string{"1"}},
+		{"pk = :pk AND sk BETWEEN :sk AND :hi", dynamodb.ComparisonOperatorBetween, []string{"1", "5"}},
+		{"pk = :pk AND begins_with(sk, :prefix)", dynamodb.ComparisonOperatorBeginsWith, []string{"2021-"}},
+	}
+
+	for _, tt := range tests {
+		program := testParse(t, tt.input)
+
+		cond, err := ToQueryKeyCondition(program, "pk", "sk", names, values)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tt.input, err)
+			continue
+		}
+
+		if cond.PartitionKey != "pk" || cond.SortKey != "sk" || boundString(cond.PartitionValue) != "user" {
+			t.Errorf("wrong partition key for %q. got=%s %s", tt.input, cond.PartitionKey, boundString(cond.PartitionValue))
+		}
+
+		if tt.operator == "" {
+			if cond.SortCondition != nil {
+				t.Errorf("unexpected sort condition for %q. got=%s", tt.input, cond.SortCondition)
+			}
+
+			continue
+		}
+
+		if cond.SortCondition == nil || *cond.SortCondition.ComparisonOperator != tt.operator {
+			t.Errorf("wrong sort condition for %q. expected=%s, got=%s", tt.input, tt.operator, cond.SortCondition)
+			continue
+		}
+
+		if len(cond.SortCondition.AttributeValueList) != len(tt.sortList) {
+			t.Errorf("wrong sort values for %q. expected=%v, got=%s", tt.input, tt.sortList, cond.SortCondition)
+			continue
+		}
+
+		for i, val := range cond.SortCondition.AttributeValueList {
+			if boundString(val) != tt.sortList[i] {
+				t.Errorf("wrong sort value at %d for %q. expected=%s, got=%s", i, tt.input, tt.sortList[i], boundString(val))
+			}
+		}
+	}
+}
+
+func TestToQueryKeyConditionNamePlaceholders(t *testing.T) {
+	values := map[string]*dynamodb.AttributeValue{
+		":pk":     {S: aws.String("user")},
+		":prefix": {S: aws.String("2021-")},
+	}
+
+	// the key attributes are reserved words, so they can only be used through placeholders
+	names := map[string]*string{
+		"#k": aws.String("key"),
+		"#d": aws.String("date"),
+	}
+
+	for _, input := range []string{"#k = :pk AND begins_with(#d, :prefix)", "begins_with(#d, :prefix) AND #k = :pk"} {
+		cond, err := ToQueryKeyCondition(testParse(t, input), "key", "date", names, values)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", input, err)
+		}
+
+		if cond.PartitionKey != "key" || boundString(cond.PartitionValue) != "user" {
+			t.Errorf("wrong partition key for %q. got=%s %s", input, cond.PartitionKey, boundString(cond.PartitionValue))
+		}
+
+		if cond.SortCondition == nil || *cond.SortCondition.ComparisonOperator != dynamodb.ComparisonOperatorBeginsWith {
+			t.Errorf("wrong sort condition for %q. got=%s", input, cond.SortCondition)
+		}
+	}
+
+	if _, err := ToQueryKeyCondition(testParse(t, "#k = :pk"), "key", "date", nil, values); err == nil {
+		t.Errorf("expected an error when the name placeholder is not defined")
+	}
+}
+
+func TestToQueryKeyConditionErrors(t *testing.T) {
+	values := map[string]*dynamodb.AttributeValue{
+		":pk": {S: aws.String("user")},
+		":sk": {N: aws.String("1")},
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sk = :sk", "the key condition must compare the partition key pk with ="},
+		{"pk < :pk", "invalid key condition: (pk < :pk)"},
+		{"pk = :pk AND other = :sk", "invalid key condition: (other = :sk)"},
+		{"pk = :pk AND sk = :sk AND sk > :sk", "invalid key condition: (sk > :sk)"},
+		{"pk = :pk AND sk <> :sk", "invalid key condition: (sk <> :sk)"},
+		{"pk = :pk AND contains(sk, :sk)", "invalid key condition: contains(sk, :sk)"},
+		{"pk = :missing", "an expression attribute value used in the key condition is not defined: :missing"},
+	}
+
+	for _, tt := range tests {
+		program := testParse(t, tt.input)
+
+		_, err := ToQueryKeyCondition(program, "pk", "sk", nil, values)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
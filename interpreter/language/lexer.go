@@ -1,5 +1,7 @@
 package language
 
+import "unicode/utf8"
+
 // Lexer DynamoDB expression lexer
 type Lexer struct {
 	input    string
@@ -8,13 +10,30 @@ type Lexer struct {
 	readPosition int
 	// current reading position in input (after current char)
 	ch byte // current char under examination
+	// line number of the current char and the offset where that line starts
+	line      int
+	lineStart int
 }
 
 var singleChar = map[byte]TokenType{
-	'=': EQ,
 	'(': LPAREN,
 	')': RPAREN,
 	',': COMMA,
+	'.': DOT,
+	'[': LBRACKET,
+	']': RBRACKET,
+	'+': PLUS,
+	'-': MINUS,
+}
+
+// operatorTypos common misspellings of the comparators with their suggested replacements,
+// they are lexed as ILLEGAL tokens to report a single error instead of a cascade
+var operatorTypos = map[string][]string{
+	"<>=": {"<>", "<="},
+	"><":  {"<>"},
+	"=<":  {"<="},
+	"=>":  {">="},
+	"==":  {"="},
 }
 
 var especialChars = map[byte]bool{
@@ -25,13 +44,18 @@ var especialChars = map[byte]bool{
 
 // NewLexer creates a new lexer
 func NewLexer(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.lineStart = l.readPosition
+	}
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -51,6 +75,12 @@ func (l *Lexer) manageLessThanToken() Token {
 		l.readChar()
 
 		tok = Token{Type: NotEQ, Literal: string(ch) + string(l.ch)}
+
+		if l.peekChar() == '=' {
+			l.readChar()
+
+			tok = Token{Type: ILLEGAL, Literal: tok.Literal + string(l.ch)}
+		}
 	case '=':
 		ch := l.ch
 		l.readChar()
@@ -72,6 +102,11 @@ func (l *Lexer) manageGreaterThanToken() Token {
 		l.readChar()
 
 		tok = Token{Type: GTE, Literal: string(ch) + string(l.ch)}
+	case '<':
+		ch := l.ch
+		l.readChar()
+
+		tok = Token{Type: ILLEGAL, Literal: string(ch) + string(l.ch)}
 	default:
 		tok = newToken(GT, l.ch)
 	}
@@ -79,12 +114,45 @@ func (l *Lexer) manageGreaterThanToken() Token {
 	return tok
 }
 
+func (l *Lexer) manageEqualToken() Token {
+	switch l.peekChar() {
+	case '<', '>', '=':
+		ch := l.ch
+		l.readChar()
+
+		return Token{Type: ILLEGAL, Literal: string(ch) + string(l.ch)}
+	}
+
+	return newToken(EQ, l.ch)
+}
+
+// manageBangToken != is only accepted by the lenient parser as an alias of <>
+func (l *Lexer) manageBangToken() Token {
+	if l.peekChar() != '=' {
+		return newToken(ILLEGAL, l.ch)
+	}
+
+	ch := l.ch
+	l.readChar()
+
+	return Token{Type: NotEQ, Literal: string(ch) + string(l.ch)}
+}
+
 // NextToken look up for the next token
 func (l *Lexer) NextToken() Token {
-	var tok Token
-
 	l.skipWhitespace()
 
+	pos := Position{Offset: l.position, Line: l.line, Column: l.position - l.lineStart + 1}
+
+	tok := l.readToken()
+	tok.Position = pos
+
+	return tok
+}
+
+func (l *Lexer) readToken() Token {
+	var tok Token
+
 	single, ok := singleChar[l.ch]
 	if ok {
 		tok = newToken(single, l.ch)
@@ -94,14 +162,24 @@ func (l *Lexer) NextToken() Token {
 	}
 
 	switch l.ch {
+	case '=':
+		tok = l.manageEqualToken()
 	case '<':
 		tok = l.manageLessThanToken()
 	case '>':
 		tok = l.manageGreaterThanToken()
+	case '!':
+		tok = l.manageBangToken()
+	case '"':
+		return l.readString()
 	case 0:
 		tok.Literal = ""
 		tok.Type = EOF
 	default:
+		if isDigit(l.ch) {
+			return l.readNumber()
+		}
+
 		if isIdentifierLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = LookupIdent(tok.Literal)
@@ -109,6 +187,10 @@ func (l *Lexer) NextToken() Token {
 			return tok
 		}
 
+		if l.ch >= utf8.RuneSelf {
+			return Token{Type: ILLEGAL, Literal: l.readRune()}
+		}
+
 		tok = newToken(ILLEGAL, l.ch)
 	}
 
@@ -117,6 +199,19 @@ func (l *Lexer) NextToken() Token {
 	return tok
 }
 
+// readRune reads the whole UTF-8 character, so the ILLEGAL token shows the character
+func (l *Lexer) readRune() string {
+	position := l.position
+
+	_, size := utf8.DecodeRuneInString(l.input[position:])
+
+	for i := 0; i < size; i++ {
+		l.readChar()
+	}
+
+	return l.input[position:l.position]
+}
+
 func (l *Lexer) readIdentifier() string {
 	position := l.position
 
@@ -127,8 +222,66 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
+// readNumber reads an INT or a FLOAT with decimals, the sign is a separate MINUS token.
+// Identifiers can not start with a digit, e.g. 1stPlace is ILLEGAL
+func (l *Lexer) readNumber() Token {
+	position := l.position
+	tokenType := INT
+
+	for isDigit(l.ch) {
+		l.readChar()
+	}
+
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = FLOAT
+
+		l.readChar()
+
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+	}
+
+	if isIdentifierLetter(l.ch) {
+		l.readIdentifier()
+
+		tokenType = ILLEGAL
+	}
+
+	return Token{Type: tokenType, Literal: l.input[position:l.position]}
+}
+
+// readString reads the quoted string with its quotes, the quotes and backslashes inside it
+// are escaped with a backslash. It is ILLEGAL without the closing quote
+func (l *Lexer) readString() Token {
+	position := l.position
+
+	for {
+		l.readChar()
+
+		switch l.ch {
+		case '\\':
+			l.readChar()
+
+			if l.ch == 0 {
+				return Token{Type: ILLEGAL, Literal: l.input[position:]}
+			}
+		case '"':
+			l.readChar()
+
+			return Token{Type: STRING, Literal: l.input[position:l.position]}
+		case 0:
+			return Token{Type: ILLEGAL, Literal: l.input[position:]}
+		}
+	}
+}
+
 func isIdentifierLetter(ch byte) bool {
-	return isLetter(ch) || '0' <= ch && ch <= '9' || especialChars[ch]
+	return isLetter(ch) || isDigit(ch) || especialChars[ch]
+}
+
+func isDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
 }
 
 func isLetter(ch byte) bool {
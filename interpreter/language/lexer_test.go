@@ -14,6 +14,14 @@ func TestNextToken(t *testing.T) {
 		`v1`: []testCase{
 			{IDENT, "v1"},
 		},
+		`a + :b - -c`: []testCase{
+			{IDENT, "a"},
+			{PLUS, "+"},
+			{ValuePlaceholder, ":b"},
+			{MINUS, "-"},
+			{MINUS, "-"},
+			{IDENT, "c"},
+		},
 		`a = b AND c`: []testCase{
 			{IDENT, "a"},
 			{EQ, "="},
@@ -21,6 +29,151 @@ func TestNextToken(t *testing.T) {
 			{AND, "AND"},
 			{IDENT, "c"},
 		},
+		`Status between a And c or NOT_A not in (b) and In`: []testCase{
+			{IDENT, "Status"},
+			{BETWEEN, "between"},
+			{IDENT, "a"},
+			{AND, "And"},
+			{IDENT, "c"},
+			{OR, "or"},
+			{IDENT, "NOT_A"},
+			{NOT, "not"},
+			{IN, "in"},
+			{LPAREN, "("},
+			{IDENT, "b"},
+			{RPAREN, ")"},
+			{AND, "and"},
+			{IN, "In"},
+		},
+		`age > 21 AND price = 1.50 AND a[0].b = "ACT\"IVE\\" AND c = -1`: []testCase{
+			{IDENT, "age"},
+			{GT, ">"},
+			{INT, "21"},
+			{AND, "AND"},
+			{IDENT, "price"},
+			{EQ, "="},
+			{FLOAT, "1.50"},
+			{AND, "AND"},
+			{IDENT, "a"},
+			{LBRACKET, "["},
+			{INT, "0"},
+			{RBRACKET, "]"},
+			{DOT, "."},
+			{IDENT, "b"},
+			{EQ, "="},
+			{STRING, `"ACT\"IVE\\"`},
+			{AND, "AND"},
+			{IDENT, "c"},
+			{EQ, "="},
+			{MINUS, "-"},
+			{INT, "1"},
+			{EOF, ""},
+		},
+		`a = "unterminated \"`: []testCase{
+			{IDENT, "a"},
+			{EQ, "="},
+			{ILLEGAL, `"unterminated \"`},
+			{EOF, ""},
+		},
+		`active = TRUE OR done = false`: []testCase{
+			{IDENT, "active"},
+			{EQ, "="},
+			{BOOL, "TRUE"},
+			{OR, "OR"},
+			{IDENT, "done"},
+			{EQ, "="},
+			{BOOL, "false"},
+		},
+		`SET a = :v, b = c REMOVE d ADD e :n DELETE f :s`: []testCase{
+			{SET, "SET"},
+			{IDENT, "a"},
+			{EQ, "="},
+			{ValuePlaceholder, ":v"},
+			{COMMA, ","},
+			{IDENT, "b"},
+			{EQ, "="},
+			{IDENT, "c"},
+			{REMOVE, "REMOVE"},
+			{IDENT, "d"},
+			{ADD, "ADD"},
+			{IDENT, "e"},
+			{ValuePlaceholder, ":n"},
+			{DELETE, "DELETE"},
+			{IDENT, "f"},
+			{ValuePlaceholder, ":s"},
+		},
+		`1stPlace = :v`: []testCase{
+			{ILLEGAL, "1stPlace"},
+			{EQ, "="},
+			{ValuePlaceholder, ":v"},
+		},
+		`#1stPlace = :v`: []testCase{
+			{NamePlaceholder, "#1stPlace"},
+			{EQ, "="},
+			{ValuePlaceholder, ":v"},
+		},
+		`#ts BETWEEN :lo AND :hi`: []testCase{
+			{NamePlaceholder, "#ts"},
+			{BETWEEN, "BETWEEN"},
+			{ValuePlaceholder, ":lo"},
+			{AND, "AND"},
+			{ValuePlaceholder, ":hi"},
+		},
+		`a.#b = : OR # = c`: []testCase{
+			{IDENT, "a"},
+			{DOT, "."},
+			{NamePlaceholder, "#b"},
+			{EQ, "="},
+			{ILLEGAL, ":"},
+			{OR, "OR"},
+			{ILLEGAL, "#"},
+			{EQ, "="},
+			{IDENT, "c"},
+		},
+		`a <>= b OR c >< d`: []testCase{
+			{IDENT, "a"},
+			{ILLEGAL, "<>="},
+			{IDENT, "b"},
+			{OR, "OR"},
+			{IDENT, "c"},
+			{ILLEGAL, "><"},
+			{IDENT, "d"},
+		},
+		`a =< b AND c => d AND e == f AND g = h`: []testCase{
+			{IDENT, "a"},
+			{ILLEGAL, "=<"},
+			{IDENT, "b"},
+			{AND, "AND"},
+			{IDENT, "c"},
+			{ILLEGAL, "=>"},
+			{IDENT, "d"},
+			{AND, "AND"},
+			{IDENT, "e"},
+			{ILLEGAL, "=="},
+			{IDENT, "f"},
+			{AND, "AND"},
+			{IDENT, "g"},
+			{EQ, "="},
+			{IDENT, "h"},
+		},
+		"a \u2212 b\u00a0= c": []testCase{
+			{IDENT, "a"},
+			{ILLEGAL, "\u2212"},
+			{IDENT, "b"},
+			{ILLEGAL, "\u00a0"},
+			{EQ, "="},
+			{IDENT, "c"},
+		},
+		`a != b`: []testCase{
+			{IDENT, "a"},
+			{NotEQ, "!="},
+			{IDENT, "b"},
+		},
+		`a ! b`: []testCase{
+			{IDENT, "a"},
+			{ILLEGAL, "!"},
+			{IDENT, "b"},
+		},
 		`a <> b`: []testCase{
 			{IDENT, "a"},
 			{NotEQ, "<>"},
@@ -29,23 +182,23 @@ func TestNextToken(t *testing.T) {
 		`attribute_exists(:a)`: []testCase{
 			{IDENT, "attribute_exists"},
 			{LPAREN, "("},
-			{IDENT, ":a"},
+			{ValuePlaceholder, ":a"},
 			{RPAREN, ")"},
 		},
 		`begins_with(:a, #s)`: []testCase{
 			{IDENT, "begins_with"},
 			{LPAREN, "("},
-			{IDENT, ":a"},
+			{ValuePlaceholder, ":a"},
 			{COMMA, ","},
-			{IDENT, "#s"},
+			{NamePlaceholder, "#s"},
 			{RPAREN, ")"},
 		},
 		`contains(:a, #s)`: []testCase{
 			{IDENT, "contains"},
 			{LPAREN, "("},
-			{IDENT, ":a"},
+			{ValuePlaceholder, ":a"},
 			{COMMA, ","},
-			{IDENT, "#s"},
+			{NamePlaceholder, "#s"},
 			{RPAREN, ")"},
 		},
 		`a <= b AND b >= c`: []testCase{
@@ -74,6 +227,25 @@ func TestNextToken(t *testing.T) {
 			{IDENT, "a"},
 			{GT, ">"},
 		},
+		`items[0] < items[10]`: []testCase{
+			{IDENT, "items"},
+			{LBRACKET, "["},
+			{INT, "0"},
+			{RBRACKET, "]"},
+			{LT, "<"},
+			{IDENT, "items"},
+			{LBRACKET, "["},
+			{INT, "10"},
+			{RBRACKET, "]"},
+		},
+		`orders[0].total`: []testCase{
+			{IDENT, "orders"},
+			{LBRACKET, "["},
+			{INT, "0"},
+			{RBRACKET, "]"},
+			{DOT, "."},
+			{IDENT, "total"},
+		},
 		`b BETWEEN a AND c`: []testCase{
 			{IDENT, "b"},
 			{BETWEEN, "BETWEEN"},
@@ -102,6 +274,33 @@ func TestNextToken(t *testing.T) {
 	}
 }
 
+func TestNextTokenPosition(t *testing.T) {
+	input := "a = :v\n  AND size(#b)\n\n>= :n"
+
+	expected := []Position{
+		{Offset: 0, Line: 1, Column: 1},
+		{Offset: 2, Line: 1, Column: 3},
+		{Offset: 4, Line: 1, Column: 5},
+		{Offset: 9, Line: 2, Column: 3},
+		{Offset: 13, Line: 2, Column: 7},
+		{Offset: 17, Line: 2, Column: 11},
+		{Offset: 18, Line: 2, Column: 12},
+		{Offset: 20, Line: 2, Column: 14},
+		{Offset: 23, Line: 4, Column: 1},
+		{Offset: 26, Line: 4, Column: 4},
+		{Offset: 28, Line: 4, Column: 6},
+	}
+
+	l := NewLexer(input)
+
+	for i, pos := range expected {
+		tok := l.NextToken()
+		if tok.Position != pos {
+			t.Errorf("tests[%d] - wrong position for %q. expected=%+v, got=%+v", i, tok.Literal, pos, tok.Position)
+		}
+	}
+}
+
 func BenchmarkLexer(b *testing.B) {
 	expected := []testCase{
 		{IDENT, "a"},
@@ -0,0 +1,35 @@
+package language
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LintPlaceholders returns warnings for the value placeholders named as a built-in
+// function, e.g. :contains, because it usually means a function call was intended.
+// The expression is still valid, so these are not errors
+func LintPlaceholders(n Node) []string {
+	warnings := []string{}
+	seen := map[string]bool{}
+
+	Walk(n, func(node Node) bool {
+		identifier, ok := node.(*Identifier)
+		if !ok || !strings.HasPrefix(identifier.Value, ":") || seen[identifier.Value] {
+			return true
+		}
+
+		seen[identifier.Value] = true
+
+		name := strings.TrimPrefix(identifier.Value, ":")
+		if _, ok := functions[name]; ok {
+			warnings = append(warnings, fmt.Sprintf("the value placeholder %q has the same name as the %s function", identifier.Value, name))
+		}
+
+		return true
+	})
+
+	sort.Strings(warnings)
+
+	return warnings
+}
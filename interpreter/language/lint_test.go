@@ -0,0 +1,58 @@
+package language
+
+import (
+	"testing"
+)
+
+func TestLintPlaceholders(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			"#status = :status",
+			[]string{},
+		},
+		{
+			"tags = :contains",
+			[]string{`the value placeholder ":contains" has the same name as the contains function`},
+		},
+		{
+			"contains(tags, :contains) AND size(a) > :size OR b = :size",
+			[]string{
+				`the value placeholder ":contains" has the same name as the contains function`,
+				`the value placeholder ":size" has the same name as the size function`,
+			},
+		},
+		{
+			"begins_with(a, :prefix)",
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		warnings := LintPlaceholders(testParse(t, tt.input))
+
+		if len(warnings) != len(tt.expected) {
+			t.Fatalf("wrong number of warnings for %q. expected=%v, got=%v", tt.input, tt.expected, warnings)
+		}
+
+		for i, w := range warnings {
+			if w != tt.expected[i] {
+				t.Errorf("wrong warning for %q. expected=%q, got=%q", tt.input, tt.expected[i], w)
+			}
+		}
+	}
+}
+
+func TestLintPlaceholdersUpdateExpression(t *testing.T) {
+	l := NewLexer("SET a = :size REMOVE b")
+	p := NewParser(l)
+	update := p.ParseUpdateExpression()
+	checkParserErrors(t, p)
+
+	warnings := LintPlaceholders(update)
+	if len(warnings) != 1 {
+		t.Fatalf("expected one warning. got=%v", warnings)
+	}
+}
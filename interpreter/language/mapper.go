@@ -1,14 +1,25 @@
 package language
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
+// ErrInvalidUTF8 when a string attribute value is not valid UTF-8, DynamoDB strings are UTF-8 so
+// these values are rejected instead of being compared by their bytes
+var ErrInvalidUTF8 = errors.New("the string attribute value is not valid UTF-8")
+
 // MapToObject convert an dynamodb attribute value to an object representation
 func MapToObject(val *dynamodb.AttributeValue) (Object, error) {
+	if val == nil {
+		return nil, fmt.Errorf("nil attribute value")
+	}
+
 	switch {
 	case val.BOOL != nil:
 		b := *val.BOOL
@@ -20,8 +31,12 @@ func MapToObject(val *dynamodb.AttributeValue) (Object, error) {
 	case val.N != nil:
 		n, err := strconv.ParseFloat(*val.N, 64)
 
-		return &Number{Value: n}, err
+		return &Number{Value: n, decimal: *val.N}, err
 	case val.S != nil:
+		if !utf8.ValidString(*val.S) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidUTF8, *val.S)
+		}
+
 		return &String{Value: *val.S}, nil
 	case val.NULL != nil && *val.NULL:
 		return &Null{}, nil
@@ -92,6 +107,10 @@ func mapAttributeToStringSet(val *dynamodb.AttributeValue) (Object, error) {
 	ss := map[string]bool{}
 
 	for _, val := range val.SS {
+		if !utf8.ValidString(*val) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidUTF8, *val)
+		}
+
 		ss[*val] = true
 	}
 
@@ -102,7 +121,7 @@ func mapAttributeToStringSet(val *dynamodb.AttributeValue) (Object, error) {
 
 func mapAttributeToBinarySet(val *dynamodb.AttributeValue) (Object, error) {
 	bs := BinarySet{
-		Value: make([][]byte, len(val.BS)),
+		Value: make([][]byte, 0, len(val.BS)),
 	}
 
 	for _, val := range val.BS {
@@ -132,3 +151,118 @@ func mapAttributeToNumberSet(val *dynamodb.AttributeValue) (Object, error) {
 		Value: ns,
 	}, nil
 }
+
+// ObjectToAttributeValue convert an object to its dynamodb attribute value representation
+func ObjectToAttributeValue(obj Object) (*dynamodb.AttributeValue, error) {
+	switch o := obj.(type) {
+	case *Boolean:
+		b := o.Value
+
+		return &dynamodb.AttributeValue{BOOL: &b}, nil
+	case *Number:
+		n := formatNumber(o)
+
+		return &dynamodb.AttributeValue{N: &n}, nil
+	case *String:
+		s := o.Value
+
+		return &dynamodb.AttributeValue{S: &s}, nil
+	case *Null:
+		if isUndefined(o) {
+			break
+		}
+
+		null := true
+
+		return &dynamodb.AttributeValue{NULL: &null}, nil
+	case *Binary:
+		return &dynamodb.AttributeValue{B: copyBytes(o.Value)}, nil
+	}
+
+	return mapComplexObjectToAttributeValue(obj)
+}
+
+func mapComplexObjectToAttributeValue(obj Object) (*dynamodb.AttributeValue, error) {
+	switch o := obj.(type) {
+	case *Map:
+		m := make(map[string]*dynamodb.AttributeValue, len(o.Value))
+
+		for k, v := range o.Value {
+			attr, err := ObjectToAttributeValue(v)
+			if err != nil {
+				return nil, err
+			}
+
+			m[k] = attr
+		}
+
+		return &dynamodb.AttributeValue{M: m}, nil
+	case *List:
+		l := make([]*dynamodb.AttributeValue, len(o.Value))
+
+		for i, v := range o.Value {
+			attr, err := ObjectToAttributeValue(v)
+			if err != nil {
+				return nil, err
+			}
+
+			l[i] = attr
+		}
+
+		return &dynamodb.AttributeValue{L: l}, nil
+	case *StringSet:
+		return &dynamodb.AttributeValue{SS: stringSetToAttribute(o)}, nil
+	case *NumberSet:
+		return &dynamodb.AttributeValue{NS: numberSetToAttribute(o)}, nil
+	case *BinarySet:
+		bs := make([][]byte, len(o.Value))
+
+		for i, b := range o.Value {
+			bs[i] = copyBytes(b)
+		}
+
+		return &dynamodb.AttributeValue{BS: bs}, nil
+	}
+
+	return nil, fmt.Errorf("the object can not be mapped to an attribute value %s", obj.Inspect())
+}
+
+func stringSetToAttribute(ss *StringSet) []*string {
+	vals := make([]string, 0, len(ss.Value))
+	for v := range ss.Value {
+		vals = append(vals, v)
+	}
+
+	sort.Strings(vals)
+
+	out := make([]*string, len(vals))
+	for i := range vals {
+		out[i] = &vals[i]
+	}
+
+	return out
+}
+
+func numberSetToAttribute(ns *NumberSet) []*string {
+	vals := make([]float64, 0, len(ns.Value))
+	for v := range ns.Value {
+		vals = append(vals, v)
+	}
+
+	sort.Float64s(vals)
+
+	out := make([]*string, len(vals))
+	for i, v := range vals {
+		n := strconv.FormatFloat(v, 'f', -1, 64)
+		out[i] = &n
+	}
+
+	return out
+}
+
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+
+	return c
+}
@@ -0,0 +1,103 @@
+package language
+
+import "strings"
+
+// Minimize returns a smaller version of the expression for which the predicate still holds, e.g.
+// to report a parser bug with a minimal reproducer. The predicate receives the first parse
+// error of each candidate, nil when it is valid. The tokens are removed with delta debugging,
+// so the result is a subsequence of the original tokens separated by spaces
+func Minimize(src string, predicate func(err error) bool) string {
+	tokens := []string{}
+	update := false
+
+	l := NewLexer(src)
+	for tok := l.NextToken(); tok.Type != EOF; tok = l.NextToken() {
+		if len(tokens) == 0 {
+			switch tok.Type {
+			case SET, REMOVE, ADD, DELETE:
+				update = true
+			}
+		}
+
+		tokens = append(tokens, tok.Literal)
+	}
+
+	holds := func(candidate []string) bool {
+		return predicate(minimizeParseError(strings.Join(candidate, " "), update))
+	}
+
+	if !holds(tokens) {
+		return src
+	}
+
+	tokens = ddmin(tokens, holds)
+
+	return strings.Join(tokens, " ")
+}
+
+// ddmin keeps a chunk of tokens or removes it while the test holds, the chunks are halved
+// when no chunk can be kept or removed until the chunks are single tokens
+func ddmin(tokens []string, test func([]string) bool) []string {
+	n := 2
+
+	for len(tokens) >= 2 {
+		if n > len(tokens) {
+			n = len(tokens)
+		}
+
+		chunk := (len(tokens) + n - 1) / n
+		reduced := false
+
+		for start := 0; start < len(tokens); start += chunk {
+			end := start + chunk
+			if end > len(tokens) {
+				end = len(tokens)
+			}
+
+			if subset := tokens[start:end]; test(subset) {
+				tokens, n, reduced = subset, 2, true
+
+				break
+			}
+
+			complement := append(append([]string{}, tokens[:start]...), tokens[end:]...)
+			if test(complement) {
+				tokens, reduced = complement, true
+
+				if n > 2 {
+					n--
+				}
+
+				break
+			}
+		}
+
+		if reduced {
+			continue
+		}
+
+		if n == len(tokens) {
+			break
+		}
+
+		n *= 2
+	}
+
+	return tokens
+}
+
+func minimizeParseError(src string, update bool) error {
+	p := NewParser(NewLexer(src))
+
+	if update {
+		p.ParseUpdateExpression()
+	} else {
+		p.ParseDynamoExpression()
+	}
+
+	if errs := p.ParseErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
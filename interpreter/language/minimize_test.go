@@ -0,0 +1,59 @@
+package language
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMinimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		message  string
+		expected string
+	}{
+		{
+			"a = :a AND (b BETWEEN :x AND :y OR contains(tags, :t)) AND c <>= :c AND attribute_exists(d)",
+			"invalid operator <>=",
+			"<>=",
+		},
+		{
+			"attribute_exists(a) AND begins_with(b, :b) OR size(c) > :n AND d != :d",
+			"the != operator is not supported",
+			"d !=",
+		},
+		{
+			"SET a = :a, b = b + :one REMOVE c, d ADD e :n, f.g :m",
+			"the ADD action only supports top level attributes",
+			"",
+		},
+		{
+			"SET a = :a, b = :b ADD views :one SET c = :c",
+			"the SET clause can only be used once",
+			"SET a = :a ADD views :one SET",
+		},
+	}
+
+	for _, tt := range tests {
+		predicate := func(err error) bool {
+			return err != nil && strings.Contains(err.Error(), tt.message)
+		}
+
+		actual := Minimize(tt.input, predicate)
+
+		if tt.expected == "" {
+			if actual != tt.input {
+				t.Errorf("expected the input when the predicate does not hold. got=%q", actual)
+			}
+
+			continue
+		}
+
+		if actual != tt.expected {
+			t.Errorf("wrong reproducer for %q. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+
+		if !predicate(minimizeParseError(actual, strings.HasPrefix(tt.input, "SET"))) {
+			t.Errorf("the predicate does not hold for the reproducer %q", actual)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package language
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// decimalPrecision maximum number of digits supported by DynamoDB numbers
+const decimalPrecision = 38
+
+// formatNumber returns the canonical DynamoDB representation of the number,
+// without exponent nor unnecessary zeros, e.g. 5.10 => 5.1
+func formatNumber(n *Number) string {
+	r, ok := numberToRat(n)
+	if !ok {
+		return strconv.FormatFloat(n.Value, 'f', -1, 64)
+	}
+
+	return formatRat(r)
+}
+
+// addNumbers adds the numbers using decimal arithmetic, e.g. 0.1 + 0.2 = 0.3
+func addNumbers(left, right *Number) Object {
+	l, ok := numberToRat(left)
+	if !ok {
+		return newError("invalid number: %s", left.Inspect())
+	}
+
+	r, ok := numberToRat(right)
+	if !ok {
+		return newError("invalid number: %s", right.Inspect())
+	}
+
+	sum := new(big.Rat).Add(l, r)
+	f, _ := sum.Float64()
+
+	return &Number{Value: f, decimal: formatRat(sum)}
+}
+
+// subtractNumbers subtracts the numbers using decimal arithmetic
+func subtractNumbers(left, right *Number) Object {
+	neg := negateNumber(right)
+	if isError(neg) {
+		return neg
+	}
+
+	return addNumbers(left, neg.(*Number))
+}
+
+// negateNumber returns the number with the opposite sign keeping its decimal representation
+func negateNumber(n *Number) Object {
+	r, ok := numberToRat(n)
+	if !ok {
+		return newError("invalid number: %s", n.Inspect())
+	}
+
+	r.Neg(r)
+	f, _ := r.Float64()
+
+	return &Number{Value: f, decimal: formatRat(r)}
+}
+
+func numberToRat(n *Number) (*big.Rat, bool) {
+	decimal := n.decimal
+	if decimal == "" {
+		decimal = strconv.FormatFloat(n.Value, 'g', -1, 64)
+	}
+
+	return new(big.Rat).SetString(decimal)
+}
+
+func formatRat(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	str := strings.TrimRight(r.FloatString(decimalPrecision), "0")
+
+	return strings.TrimSuffix(str, ".")
+}
@@ -0,0 +1,55 @@
+package language
+
+import "testing"
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		input    *Number
+		expected string
+	}{
+		{&Number{Value: 5}, "5"},
+		{&Number{Value: 0.5}, "0.5"},
+		{&Number{Value: -12.25}, "-12.25"},
+		{&Number{Value: 1e21}, "1000000000000000000000"},
+		{&Number{Value: 5.1, decimal: "5.10"}, "5.1"},
+		{&Number{Value: 100, decimal: "1E2"}, "100"},
+		{&Number{Value: 1.2345678901234568e28, decimal: "12345678901234567890123456789"}, "12345678901234567890123456789"},
+	}
+
+	for _, tt := range tests {
+		actual := formatNumber(tt.input)
+		if actual != tt.expected {
+			t.Errorf("wrong format for %s. expected=%q, got=%q", tt.input.Inspect(), tt.expected, actual)
+		}
+	}
+}
+
+func TestAddNumbers(t *testing.T) {
+	tests := []struct {
+		left     *Number
+		right    *Number
+		expected string
+	}{
+		{&Number{Value: 2}, &Number{Value: 3}, "5"},
+		{&Number{Value: 0.1}, &Number{Value: 0.2}, "0.3"},
+		{&Number{Value: 0.1, decimal: "0.1"}, &Number{Value: -0.3, decimal: "-0.3"}, "-0.2"},
+		{
+			&Number{Value: 1.2345678901234568e28, decimal: "12345678901234567890123456789"},
+			&Number{Value: 1, decimal: "1"},
+			"12345678901234567890123456790",
+		},
+	}
+
+	for _, tt := range tests {
+		sum := addNumbers(tt.left, tt.right)
+
+		n, ok := sum.(*Number)
+		if !ok {
+			t.Fatalf("unexpected result %s", sum.Inspect())
+		}
+
+		if actual := formatNumber(n); actual != tt.expected {
+			t.Errorf("wrong sum for %s + %s. expected=%q, got=%q", tt.left.Inspect(), tt.right.Inspect(), tt.expected, actual)
+		}
+	}
+}
@@ -8,8 +8,8 @@ import (
 )
 
 var (
-	// NULL definel the global null value
-	NULL = &Null{}
+	// NULL definel the global value used for undefined attributes
+	NULL = &Null{undefined: true}
 	// TRUE definel the global true value
 	TRUE = &Boolean{Value: true}
 	// FALSE definel the global false value
@@ -88,6 +88,8 @@ type ContainerObject interface {
 // Number is the representation of numbers
 type Number struct {
 	Value float64
+	// decimal is the exact representation used by the update results, empty when unknown
+	decimal string
 }
 
 // Inspect returns the readable value of the object
@@ -154,7 +156,11 @@ func (b *Binary) CanContain(objType ObjectType) bool {
 }
 
 // Null is the representation of nil values
-type Null struct{}
+type Null struct {
+	// undefined is set when the value represents a missing attribute
+	// instead of a NULL typed value
+	undefined bool
+}
 
 // Type returns the object type
 func (n *Null) Type() ObjectType { return ObjectTypeNull }
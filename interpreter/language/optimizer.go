@@ -0,0 +1,538 @@
+package language
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// OptimizeFilter returns an equivalent expression where the AND conjuncts are
+// reordered cheapest first to take advantage of the short-circuit evaluation.
+//
+// Terms that may produce an evaluation error are never moved, and no term is moved
+// across them, so an expression that fails keeps failing after the optimization.
+func OptimizeFilter(expr *DynamoExpression) *DynamoExpression {
+	stmt, ok := expr.Statement.(*ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return expr
+	}
+
+	return &DynamoExpression{
+		Statement: &ExpressionStatement{
+			Token:      stmt.Token,
+			Expression: optimizeExpression(stmt.Expression),
+		},
+	}
+}
+
+func optimizeExpression(exp Expression) Expression {
+	switch node := exp.(type) {
+	case *InfixExpression:
+		if node.Operator == AND {
+			return optimizeConjunction(node)
+		}
+
+		if node.Operator == OR {
+			return &InfixExpression{
+				Token:    node.Token,
+				Operator: node.Operator,
+				Left:     optimizeExpression(node.Left),
+				Right:    optimizeExpression(node.Right),
+			}
+		}
+	case *PrefixExpression:
+		return &PrefixExpression{
+			Token:    node.Token,
+			Operator: node.Operator,
+			Right:    optimizeExpression(node.Right),
+		}
+	}
+
+	return exp
+}
+
+func optimizeConjunction(node *InfixExpression) Expression {
+	terms := Conjuncts(node)
+
+	for i, term := range terms {
+		terms[i] = optimizeExpression(term)
+	}
+
+	// sort the runs of terms delimited by the ones that may fail
+	start := 0
+
+	for i := 0; i <= len(terms); i++ {
+		if i < len(terms) && !mayFail(terms[i]) {
+			continue
+		}
+
+		run := terms[start:i]
+		sort.SliceStable(run, func(a, b int) bool {
+			return CostEstimate(run[a]) < CostEstimate(run[b])
+		})
+
+		start = i + 1
+	}
+
+	result := terms[0]
+
+	for _, term := range terms[1:] {
+		result = &InfixExpression{
+			Token:    node.Token,
+			Operator: node.Operator,
+			Left:     result,
+			Right:    term,
+		}
+	}
+
+	return result
+}
+
+// Conjuncts returns the terms of the AND chain, e.g. a AND b AND c => [a, b, c]
+func Conjuncts(exp Expression) []Expression {
+	return flattenLogical(exp, AND)
+}
+
+// Disjuncts returns the terms of the OR chain, e.g. a OR b OR c => [a, b, c]
+func Disjuncts(exp Expression) []Expression {
+	return flattenLogical(exp, OR)
+}
+
+// flattenLogical uses a stack instead of recursion to support long chains of terms
+func flattenLogical(exp Expression, operator string) []Expression {
+	terms := []Expression{}
+	stack := []Expression{exp}
+
+	for len(stack) > 0 {
+		last := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		infix, ok := last.(*InfixExpression)
+		if !ok || infix.Operator != operator {
+			terms = append(terms, last)
+			continue
+		}
+
+		stack = append(stack, infix.Right, infix.Left)
+	}
+
+	return terms
+}
+
+// mayFail reports whether the condition could produce an evaluation error
+func mayFail(exp Expression) bool {
+	switch node := exp.(type) {
+	case *InfixExpression:
+		switch node.Operator {
+		case AND, OR:
+			return mayFail(node.Left) || mayFail(node.Right)
+		case EQ, NotEQ:
+			// comparing different types for equality is false instead of an error
+			return operandMayFail(node.Left) || operandMayFail(node.Right)
+		}
+	case *PrefixExpression:
+		return mayFail(node.Right)
+	case *InExpression:
+		for _, c := range node.Candidates {
+			if operandMayFail(c) {
+				return true
+			}
+		}
+
+		return operandMayFail(node.Left)
+	case *CallExpression:
+		identifier, ok := node.Function.(*Identifier)
+		if !ok {
+			return true
+		}
+
+		switch identifier.Value {
+		case "attribute_exists", "attribute_not_exists":
+			return len(node.Arguments) != 1 || operandMayFail(node.Arguments[0])
+		}
+	}
+
+	return true
+}
+
+func operandMayFail(exp Expression) bool {
+	switch exp.(type) {
+	case *Identifier, *IndexExpression, *BoolLiteral, *NumberLiteral, *StringLiteral:
+		return false
+	}
+
+	return true
+}
+
+// ProjectFilter splits the AND terms of the filter between the ones referencing only
+// the given attributes (retained) and the rest (residual), both are nil when empty.
+// Evaluating both parts is equivalent to evaluating the whole filter
+func ProjectFilter(expr *DynamoExpression, attrs []string) (retained Expression, residual Expression) {
+	stmt, ok := expr.Statement.(*ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return nil, nil
+	}
+
+	allowed := map[string]bool{}
+	for _, attr := range attrs {
+		allowed[attr] = true
+	}
+
+	var retainedTerms, residualTerms []Expression
+
+	for _, term := range Conjuncts(stmt.Expression) {
+		if onlyReferences(term, allowed) {
+			retainedTerms = append(retainedTerms, term)
+		} else {
+			residualTerms = append(residualTerms, term)
+		}
+	}
+
+	return joinConjuncts(retainedTerms), joinConjuncts(residualTerms)
+}
+
+func onlyReferences(term Expression, allowed map[string]bool) bool {
+	for _, attr := range referencedAttributes(term) {
+		if !allowed[attr] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReferencedAttributes returns the top level attributes used by the expression in order
+// of appearance and without duplicates, the aliases are not resolved
+func ReferencedAttributes(exp Expression) []string {
+	seen := map[string]bool{}
+	attrs := []string{}
+
+	for _, attr := range referencedAttributes(exp) {
+		if !seen[attr] {
+			seen[attr] = true
+			attrs = append(attrs, attr)
+		}
+	}
+
+	return attrs
+}
+
+// referencedAttributes returns the top level attributes used by the expression,
+// the function names, the placeholders and the nested map keys are excluded
+func referencedAttributes(exp Expression) []string {
+	attrs := []string{}
+
+	var visit func(Node) bool
+
+	visit = func(n Node) bool {
+		switch node := n.(type) {
+		case *CallExpression:
+			for _, arg := range node.Arguments {
+				Walk(arg, visit)
+			}
+
+			return false
+		case *IndexExpression:
+			Walk(node.Left, visit)
+
+			return false
+		case *Identifier:
+			if !strings.HasPrefix(node.Value, ":") {
+				attrs = append(attrs, node.Value)
+			}
+		}
+
+		return true
+	}
+
+	Walk(exp, visit)
+
+	return attrs
+}
+
+func joinConjuncts(terms []Expression) Expression {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	result := terms[0]
+
+	for _, term := range terms[1:] {
+		result = &InfixExpression{
+			Token:    Token{Type: AND, Literal: AND},
+			Operator: AND,
+			Left:     result,
+			Right:    term,
+		}
+	}
+
+	return result
+}
+
+// PrecomputeIn returns an equivalent expression where the IN operands composed only by
+// scalar placeholders are replaced by a hash set of their values, so the membership is
+// checked in constant time. The result must be evaluated with the same placeholder values
+func PrecomputeIn(expr *DynamoExpression, values map[string]*dynamodb.AttributeValue) *DynamoExpression {
+	stmt, ok := expr.Statement.(*ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return expr
+	}
+
+	return &DynamoExpression{
+		Statement: &ExpressionStatement{
+			Token:      stmt.Token,
+			Expression: precomputeIn(stmt.Expression, values),
+		},
+	}
+}
+
+func precomputeIn(exp Expression, values map[string]*dynamodb.AttributeValue) Expression {
+	switch node := exp.(type) {
+	case *InfixExpression:
+		if node.Operator != AND && node.Operator != OR {
+			return exp
+		}
+
+		return &InfixExpression{
+			Token:    node.Token,
+			Operator: node.Operator,
+			Left:     precomputeIn(node.Left, values),
+			Right:    precomputeIn(node.Right, values),
+		}
+	case *PrefixExpression:
+		return &PrefixExpression{
+			Token:    node.Token,
+			Operator: node.Operator,
+			Right:    precomputeIn(node.Right, values),
+		}
+	case *InExpression:
+		set, ok := candidatesSet(node.Candidates, values)
+		if !ok {
+			return exp
+		}
+
+		return &InExpression{
+			Token:      node.Token,
+			Left:       node.Left,
+			Candidates: node.Candidates,
+			values:     set,
+		}
+	}
+
+	return exp
+}
+
+// candidatesSet fails when a candidate is a path or its value is missing or not scalar
+func candidatesSet(candidates []Expression, values map[string]*dynamodb.AttributeValue) (map[string]bool, bool) {
+	set := make(map[string]bool, len(candidates))
+
+	for _, c := range candidates {
+		identifier, ok := c.(*Identifier)
+		if !ok || !strings.HasPrefix(identifier.Value, ":") {
+			return nil, false
+		}
+
+		val, ok := values[identifier.Value]
+		if !ok || val == nil {
+			return nil, false
+		}
+
+		obj, err := MapToObject(val)
+		if err != nil {
+			return nil, false
+		}
+
+		key, ok := hashKey(obj)
+		if !ok {
+			return nil, false
+		}
+
+		set[key] = true
+	}
+
+	return set, true
+}
+
+// hashKey returns a key that is equal for the scalar values that are equal
+func hashKey(obj Object) (string, bool) {
+	if isUndefined(obj) {
+		return "", false
+	}
+
+	switch o := obj.(type) {
+	case *Number:
+		if o.Value == 0 {
+			return "N:0", true
+		}
+
+		return "N:" + strconv.FormatFloat(o.Value, 'g', -1, 64), true
+	case *String:
+		return "S:" + o.Value, true
+	case *Binary:
+		return "B:" + string(o.Value), true
+	case *Boolean:
+		return "BOOL:" + strconv.FormatBool(o.Value), true
+	case *Null:
+		return "NULL", true
+	}
+
+	return "", false
+}
+
+// EliminateCommonSubexpressions returns an equivalent expression where the conditions repeated
+// in the AND/OR/NOT tree are evaluated at most once per environment, e.g. a = :x in
+// (a = :x AND b = :y) OR (a = :x AND c = :z). A new environment must be used for each item,
+// the previous results are discarded when the environment variables change
+func EliminateCommonSubexpressions(expr *DynamoExpression) *DynamoExpression {
+	stmt, ok := expr.Statement.(*ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return expr
+	}
+
+	counts := map[string]int{}
+	countLeaves(stmt.Expression, counts)
+
+	return &DynamoExpression{
+		Statement: &ExpressionStatement{
+			Token:      stmt.Token,
+			Expression: shareLeaves(stmt.Expression, counts, map[string]*sharedExpression{}),
+		},
+	}
+}
+
+// countLeaves counts the conditions of the logical tree by their string representation
+func countLeaves(exp Expression, counts map[string]int) {
+	switch node := exp.(type) {
+	case *InfixExpression:
+		if node.Operator == AND || node.Operator == OR {
+			countLeaves(node.Left, counts)
+			countLeaves(node.Right, counts)
+
+			return
+		}
+	case *PrefixExpression:
+		countLeaves(node.Right, counts)
+
+		return
+	}
+
+	counts[exp.String()]++
+}
+
+func shareLeaves(exp Expression, counts map[string]int, shared map[string]*sharedExpression) Expression {
+	switch node := exp.(type) {
+	case *InfixExpression:
+		if node.Operator == AND || node.Operator == OR {
+			return &InfixExpression{
+				Token:    node.Token,
+				Operator: node.Operator,
+				Left:     shareLeaves(node.Left, counts, shared),
+				Right:    shareLeaves(node.Right, counts, shared),
+			}
+		}
+	case *PrefixExpression:
+		return &PrefixExpression{
+			Token:    node.Token,
+			Operator: node.Operator,
+			Right:    shareLeaves(node.Right, counts, shared),
+		}
+	}
+
+	key := exp.String()
+	if counts[key] < 2 {
+		return exp
+	}
+
+	if _, ok := shared[key]; !ok {
+		shared[key] = &sharedExpression{Expression: exp}
+	}
+
+	return shared[key]
+}
+
+// PrecomputeAccessors returns an equivalent expression where the operands that are top level
+// attributes are resolved once with the names, so the evaluation skips the alias resolution.
+// The result must be evaluated with the same names as the environment aliases
+func PrecomputeAccessors(expr *DynamoExpression, names map[string]string) *DynamoExpression {
+	stmt, ok := expr.Statement.(*ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return expr
+	}
+
+	return &DynamoExpression{
+		Statement: &ExpressionStatement{
+			Token:      stmt.Token,
+			Expression: precomputeAccessors(stmt.Expression, names, map[*sharedExpression]*sharedExpression{}),
+		},
+	}
+}
+
+// precomputeAccessors keeps a single copy of every shared expression, the document paths
+// are not modified because they are resolved by the index expressions
+func precomputeAccessors(exp Expression, names map[string]string, shared map[*sharedExpression]*sharedExpression) Expression {
+	switch node := exp.(type) {
+	case *Identifier:
+		key := node.Value
+		if name, ok := names[key]; ok {
+			key = name
+		}
+
+		return &attributeAccessor{Identifier: node, key: key}
+	case *PrefixExpression:
+		return &PrefixExpression{
+			Token:    node.Token,
+			Operator: node.Operator,
+			Right:    precomputeAccessors(node.Right, names, shared),
+		}
+	case *InfixExpression:
+		return &InfixExpression{
+			Token:    node.Token,
+			Operator: node.Operator,
+			Left:     precomputeAccessors(node.Left, names, shared),
+			Right:    precomputeAccessors(node.Right, names, shared),
+		}
+	case *BetweenExpression:
+		return &BetweenExpression{
+			Token: node.Token,
+			Left:  precomputeAccessors(node.Left, names, shared),
+			Range: [2]Expression{
+				precomputeAccessors(node.Range[0], names, shared),
+				precomputeAccessors(node.Range[1], names, shared),
+			},
+		}
+	case *InExpression:
+		candidates := make([]Expression, len(node.Candidates))
+		for i, c := range node.Candidates {
+			candidates[i] = precomputeAccessors(c, names, shared)
+		}
+
+		return &InExpression{
+			Token:      node.Token,
+			Left:       precomputeAccessors(node.Left, names, shared),
+			Candidates: candidates,
+			values:     node.values,
+		}
+	case *CallExpression:
+		// the function name is not an attribute
+		args := make([]Expression, len(node.Arguments))
+		for i, arg := range node.Arguments {
+			args[i] = precomputeAccessors(arg, names, shared)
+		}
+
+		return &CallExpression{
+			Token:     node.Token,
+			Function:  node.Function,
+			Arguments: args,
+		}
+	case *sharedExpression:
+		if _, ok := shared[node]; !ok {
+			shared[node] = &sharedExpression{Expression: precomputeAccessors(node.Expression, names, shared)}
+		}
+
+		return shared[node]
+	}
+
+	return exp
+}
@@ -0,0 +1,545 @@
+package language
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestOptimizeFilter(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"attribute_exists(d) AND a = :x",
+			"((a = :x) AND attribute_exists(d))",
+		},
+		{
+			"attribute_not_exists(c) AND a = :x AND b < :y AND attribute_exists(d) AND e = :z",
+			"(((((a = :x) AND attribute_not_exists(c)) AND (b < :y)) AND (e = :z)) AND attribute_exists(d))",
+		},
+		{
+			"size(a) > :n AND a = :x",
+			"((size(a) > :n) AND (a = :x))",
+		},
+		{
+			"(attribute_exists(d) AND a = :x) OR NOT (attribute_exists(c) AND b = :y)",
+			"(((a = :x) AND attribute_exists(d)) OR (NOT ((b = :y) AND attribute_exists(c))))",
+		},
+		{
+			"a = :x",
+			"(a = :x)",
+		},
+	}
+
+	for _, tt := range tests {
+		program := testParse(t, tt.input)
+		original := program.String()
+
+		actual := OptimizeFilter(program).String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+
+		if program.String() != original {
+			t.Errorf("the original expression was modified. got=%q", program.String())
+		}
+	}
+}
+
+func TestOptimizeFilterEquivalence(t *testing.T) {
+	inputs := []string{
+		"attribute_not_exists(c) AND a = :x AND b < :y AND attribute_exists(d) AND e = :z",
+		"attribute_exists(d) AND a <> :x OR attribute_not_exists(a) AND e = :z",
+		"NOT (attribute_exists(c) AND b = :y) AND a = :x",
+	}
+
+	items := []map[string]*dynamodb.AttributeValue{
+		{},
+		{"a": {S: aws.String("x")}, "b": {N: aws.String("1")}, "d": {BOOL: aws.Bool(true)}, "e": {S: aws.String("z")}},
+		{"a": {S: aws.String("x")}, "b": {N: aws.String("5")}, "c": {S: aws.String("c")}, "e": {S: aws.String("z")}},
+		{"a": {S: aws.String("w")}, "b": {S: aws.String("y")}, "c": {S: aws.String("c")}},
+	}
+
+	for _, input := range inputs {
+		program := testParse(t, input)
+		optimized := OptimizeFilter(program)
+
+		for i, item := range items {
+			env := newOptimizerTestEnvironment(t, item)
+
+			expected := Eval(program, env)
+			actual := Eval(optimized, env)
+
+			if expected.Inspect() != actual.Inspect() {
+				t.Errorf("(%d) %q is not equivalent after the optimization. expected=%s, got=%s", i, input, expected.Inspect(), actual.Inspect())
+			}
+		}
+	}
+}
+
+func newOptimizerTestEnvironment(t testing.TB, item map[string]*dynamodb.AttributeValue) *Environment {
+	env := NewEnvironment()
+
+	err := env.AddAttributes(item)
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	err = env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		":x":       {S: aws.String("x")},
+		":y":       {N: aws.String("3")},
+		":z":       {S: aws.String("z")},
+		":missing": {S: aws.String("missing")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	return env
+}
+
+func BenchmarkOptimizeFilter(b *testing.B) {
+	input := "attribute_not_exists(a) AND attribute_not_exists(b) AND attribute_not_exists(c) AND id = :missing"
+
+	l := NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseDynamoExpression()
+
+	env := newOptimizerTestEnvironment(b, map[string]*dynamodb.AttributeValue{
+		"id": {S: aws.String("001")},
+	})
+
+	benchmarks := map[string]*DynamoExpression{
+		"original":  program,
+		"optimized": OptimizeFilter(program),
+	}
+
+	for name, expr := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				if Eval(expr, env) != FALSE {
+					b.Fatal("expected to be false")
+				}
+			}
+		})
+	}
+}
+
+func TestProjectFilter(t *testing.T) {
+	tests := []struct {
+		input    string
+		attrs    []string
+		retained string
+		residual string
+	}{
+		{"a = :x AND b = :y", []string{"a"}, "(a = :x)", "(b = :y)"},
+		{"a = :x AND b = :y", []string{"a", "b"}, "((a = :x) AND (b = :y))", ""},
+		{"a = :x AND b = :y", []string{"c"}, "", "((a = :x) AND (b = :y))"},
+		{"a = :x AND size(a.b[0]) > :n AND contains(c, :y)", []string{"a"}, "((a = :x) AND (size(a.b[0]) > :n))", "contains(c, :y)"},
+		{"a = :x AND (a = :y OR b = :y)", []string{"a"}, "(a = :x)", "((a = :y) OR (b = :y))"},
+		{"a = :x OR b = :y", []string{"a"}, "", "((a = :x) OR (b = :y))"},
+	}
+
+	for _, tt := range tests {
+		program := testParse(t, tt.input)
+
+		retained, residual := ProjectFilter(program, tt.attrs)
+
+		if actual := expressionString(retained); actual != tt.retained {
+			t.Errorf("wrong retained filter for %q. expected=%q, got=%q", tt.input, tt.retained, actual)
+		}
+
+		if actual := expressionString(residual); actual != tt.residual {
+			t.Errorf("wrong residual filter for %q. expected=%q, got=%q", tt.input, tt.residual, actual)
+		}
+	}
+}
+
+func expressionString(exp Expression) string {
+	if exp == nil {
+		return ""
+	}
+
+	return exp.String()
+}
+
+func TestConjunctsAndDisjuncts(t *testing.T) {
+	tests := []struct {
+		input     string
+		conjuncts []string
+		disjuncts []string
+	}{
+		{"a = :x", []string{"(a = :x)"}, []string{"(a = :x)"}},
+		{"a = :x AND b = :y AND c = :z", []string{"(a = :x)", "(b = :y)", "(c = :z)"}, []string{"(((a = :x) AND (b = :y)) AND (c = :z))"}},
+		{"a = :x OR b = :y OR c = :z", []string{"(((a = :x) OR (b = :y)) OR (c = :z))"}, []string{"(a = :x)", "(b = :y)", "(c = :z)"}},
+		{"a = :x OR (b = :y OR c = :z) AND d", []string{"((a = :x) OR (((b = :y) OR (c = :z)) AND d))"}, []string{"(a = :x)", "(((b = :y) OR (c = :z)) AND d)"}},
+	}
+
+	for _, tt := range tests {
+		exp := testParse(t, tt.input).Statement.(*ExpressionStatement).Expression
+
+		testTerms(t, tt.input, Conjuncts(exp), tt.conjuncts)
+		testTerms(t, tt.input, Disjuncts(exp), tt.disjuncts)
+	}
+}
+
+func TestDisjunctsManyTerms(t *testing.T) {
+	terms := make([]string, 50)
+	for i := range terms {
+		terms[i] = fmt.Sprintf("id = :v%d", i)
+	}
+
+	exp := testParse(t, strings.Join(terms, " OR ")).Statement.(*ExpressionStatement).Expression
+
+	disjuncts := Disjuncts(exp)
+	if len(disjuncts) != len(terms) {
+		t.Fatalf("wrong number of disjuncts. expected=%d, got=%d", len(terms), len(disjuncts))
+	}
+
+	for i, term := range disjuncts {
+		expected := fmt.Sprintf("(id = :v%d)", i)
+		if term.String() != expected {
+			t.Errorf("wrong disjunct at %d. expected=%q, got=%q", i, expected, term.String())
+		}
+	}
+}
+
+func testTerms(t *testing.T, input string, terms []Expression, expected []string) {
+	if len(terms) != len(expected) {
+		t.Errorf("wrong number of terms for %q. expected=%d, got=%d", input, len(expected), len(terms))
+		return
+	}
+
+	for i, term := range terms {
+		if term.String() != expected[i] {
+			t.Errorf("wrong term at %d for %q. expected=%q, got=%q", i, input, expected[i], term.String())
+		}
+	}
+}
+
+func TestPrecomputeIn(t *testing.T) {
+	values := map[string]*dynamodb.AttributeValue{
+		":one":  {N: aws.String("1")},
+		":two":  {N: aws.String("2.0")},
+		":txt":  {S: aws.String("txt")},
+		":bin":  {B: []byte("txt")},
+		":yes":  {BOOL: aws.Bool(true)},
+		":nil":  {NULL: aws.Bool(true)},
+		":list": {L: []*dynamodb.AttributeValue{{S: aws.String("txt")}}},
+	}
+
+	inputs := []struct {
+		input  string
+		hashed bool
+	}{
+		{"a IN (:one, :two, :txt, :bin, :yes, :nil)", true},
+		{"NOT a IN (:one, :txt) OR b = :one", true},
+		{"a IN (:one, b)", false},
+		{"a IN (:one, :list)", false},
+		{"a IN (:one, :missing)", false},
+	}
+
+	items := []map[string]*dynamodb.AttributeValue{
+		{},
+		{"a": {N: aws.String("1.0")}},
+		{"a": {N: aws.String("2")}},
+		{"a": {N: aws.String("3")}},
+		{"a": {S: aws.String("txt")}},
+		{"a": {B: []byte("txt")}},
+		{"a": {BOOL: aws.Bool(true)}},
+		{"a": {BOOL: aws.Bool(false)}},
+		{"a": {NULL: aws.Bool(true)}},
+		{"a": {L: []*dynamodb.AttributeValue{{S: aws.String("txt")}}}, "b": {N: aws.String("1")}},
+	}
+
+	for _, tt := range inputs {
+		program := testParse(t, tt.input)
+		precomputed := PrecomputeIn(program, values)
+
+		hashed := false
+
+		Walk(precomputed, func(n Node) bool {
+			if in, ok := n.(*InExpression); ok && in.values != nil {
+				hashed = true
+			}
+
+			return true
+		})
+
+		if hashed != tt.hashed {
+			t.Errorf("wrong precomputation for %q. expected=%t, got=%t", tt.input, tt.hashed, hashed)
+		}
+
+		for i, item := range items {
+			env := NewEnvironment()
+
+			err := env.AddAttributes(item)
+			if err != nil {
+				t.Fatalf("error adding attributes %#v", err)
+			}
+
+			err = env.AddAttributes(values)
+			if err != nil {
+				t.Fatalf("error adding attributes %#v", err)
+			}
+
+			expected := Eval(program, env)
+			actual := Eval(precomputed, env)
+
+			if expected.Inspect() != actual.Inspect() {
+				t.Errorf("(%d) %q is not equivalent after the precomputation. expected=%s, got=%s", i, tt.input, expected.Inspect(), actual.Inspect())
+			}
+		}
+	}
+}
+
+func BenchmarkPrecomputeIn(b *testing.B) {
+	candidates := make([]string, 100)
+	values := map[string]*dynamodb.AttributeValue{}
+
+	for i := range candidates {
+		candidates[i] = fmt.Sprintf(":v%d", i)
+		values[candidates[i]] = &dynamodb.AttributeValue{S: aws.String(fmt.Sprintf("value-%d", i))}
+	}
+
+	l := NewLexer("a IN (" + strings.Join(candidates, ", ") + ")")
+	p := NewParser(l)
+	program := p.ParseDynamoExpression()
+
+	envs := make([]*Environment, 1000)
+
+	for i := range envs {
+		envs[i] = NewEnvironment()
+
+		err := envs[i].AddAttributes(map[string]*dynamodb.AttributeValue{
+			"a": {S: aws.String(fmt.Sprintf("value-%d", i%200))},
+		})
+		if err != nil {
+			b.Fatalf("error adding attributes %#v", err)
+		}
+
+		err = envs[i].AddAttributes(values)
+		if err != nil {
+			b.Fatalf("error adding attributes %#v", err)
+		}
+	}
+
+	benchmarks := map[string]*DynamoExpression{
+		"linear": program,
+		"hashed": PrecomputeIn(program, values),
+	}
+
+	for name, expr := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, env := range envs {
+					Eval(expr, env)
+				}
+			}
+		})
+	}
+}
+
+func TestReferencedAttributes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"a = :x", []string{"a"}},
+		{"a > :x AND a < :y OR #b = :x", []string{"a", "#b"}},
+		{"size(a.b[0]) > :n AND contains(c, a)", []string{"a", "c"}},
+	}
+
+	for _, tt := range tests {
+		exp := testParse(t, tt.input).Statement.(*ExpressionStatement).Expression
+
+		actual := ReferencedAttributes(exp)
+		if strings.Join(actual, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("wrong attributes for %q. expected=%v, got=%v", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestEliminateCommonSubexpressions(t *testing.T) {
+	inputs := []string{
+		"(a = :x AND b = :y) OR (a = :x AND e = :z)",
+		"(a = :x AND b < :y) OR NOT (b < :y) OR attribute_exists(d)",
+		"(attribute_exists(d) OR a = :x) AND (attribute_exists(d) OR e = :z) AND b = :y",
+		"(size(a) > :y AND b = :y) OR (size(a) > :y AND c = :x)",
+		"a = :x OR b = :y",
+	}
+
+	items := []map[string]*dynamodb.AttributeValue{
+		{},
+		{"a": {S: aws.String("x")}, "b": {N: aws.String("1")}, "d": {BOOL: aws.Bool(true)}, "e": {S: aws.String("z")}},
+		{"a": {S: aws.String("x")}, "b": {N: aws.String("3")}, "c": {S: aws.String("c")}, "e": {S: aws.String("z")}},
+		{"a": {S: aws.String("w")}, "b": {S: aws.String("y")}, "c": {S: aws.String("x")}},
+	}
+
+	for _, input := range inputs {
+		program := testParse(t, input)
+		original := program.String()
+
+		optimized := EliminateCommonSubexpressions(program)
+		if optimized.String() != original {
+			t.Errorf("the expression changed after the elimination. expected=%q, got=%q", original, optimized.String())
+		}
+
+		for i, item := range items {
+			expected := Eval(program, newOptimizerTestEnvironment(t, item))
+			actual := Eval(optimized, newOptimizerTestEnvironment(t, item))
+
+			if expected.Inspect() != actual.Inspect() {
+				t.Errorf("(%d) %q is not equivalent after the elimination. expected=%s, got=%s", i, input, expected.Inspect(), actual.Inspect())
+			}
+		}
+	}
+}
+
+func TestEliminateCommonSubexpressionsEvaluatesOnce(t *testing.T) {
+	program := testParse(t, "(a = :x AND b = :y) OR (a = :x AND e = :z)")
+	optimized := EliminateCommonSubexpressions(program)
+
+	tests := []struct {
+		expr     *DynamoExpression
+		expected int
+	}{
+		{program, 4},
+		{optimized, 3},
+	}
+
+	for _, tt := range tests {
+		comparisons := 0
+
+		env := newOptimizerTestEnvironment(t, map[string]*dynamodb.AttributeValue{
+			"a": {S: aws.String("x")},
+			"b": {N: aws.String("1")},
+			"e": {S: aws.String("z")},
+		})
+		env.CompareHook = func(op string, left, right *dynamodb.AttributeValue) (int, bool, error) {
+			comparisons++
+
+			return 0, false, nil
+		}
+
+		if Eval(tt.expr, env) != TRUE {
+			t.Fatalf("expected %q to be true", tt.expr.String())
+		}
+
+		if comparisons != tt.expected {
+			t.Errorf("wrong number of comparisons. expected=%d, got=%d", tt.expected, comparisons)
+		}
+	}
+}
+
+func BenchmarkEliminateCommonSubexpressions(b *testing.B) {
+	input := "(contains(tags, :x) AND a = :x) OR (contains(tags, :x) AND b = :y) OR (contains(tags, :x) AND e = :z)"
+
+	l := NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseDynamoExpression()
+
+	item := map[string]*dynamodb.AttributeValue{
+		"tags": {SS: aws.StringSlice([]string{"v", "w", "x"})},
+		"e":    {S: aws.String("z")},
+	}
+
+	benchmarks := map[string]*DynamoExpression{
+		"original":   program,
+		"eliminated": EliminateCommonSubexpressions(program),
+	}
+
+	for name, expr := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				env := newOptimizerTestEnvironment(b, item)
+				b.StartTimer()
+
+				if Eval(expr, env) != TRUE {
+					b.Fatal("expected to be true")
+				}
+			}
+		})
+	}
+}
+
+func TestPrecomputeAccessors(t *testing.T) {
+	names := map[string]string{"#a": "a", "#t": "tags"}
+
+	inputs := []string{
+		"a = :x",
+		"#a = :x AND b < :y",
+		"NOT #a <> a OR missing = :x",
+		"b BETWEEN :y AND :y",
+		"#a IN (:z, c, :x)",
+		"contains(#t, :x) AND size(#t) = :y AND attribute_not_exists(missing)",
+		"m.#a = :x OR l[0] = :z",
+	}
+
+	items := []map[string]*dynamodb.AttributeValue{
+		{},
+		{"a": {S: aws.String("x")}, "b": {N: aws.String("3")}, "c": {S: aws.String("c")}},
+		{
+			"a":    {S: aws.String("z")},
+			"tags": {SS: aws.StringSlice([]string{"x", "y", "z"})},
+			"m":    {M: map[string]*dynamodb.AttributeValue{"a": {S: aws.String("x")}}},
+			"l":    {L: []*dynamodb.AttributeValue{{S: aws.String("z")}}},
+		},
+	}
+
+	for _, input := range inputs {
+		program := testParse(t, input)
+		precomputed := PrecomputeAccessors(EliminateCommonSubexpressions(program), names)
+
+		if precomputed.String() != program.String() {
+			t.Errorf("the expression changed after the precomputation. expected=%q, got=%q", program.String(), precomputed.String())
+		}
+
+		for i, item := range items {
+			env := newOptimizerTestEnvironment(t, item)
+			env.Aliases = names
+
+			expected := Eval(program, env)
+			actual := Eval(precomputed, env)
+
+			if expected.Inspect() != actual.Inspect() {
+				t.Errorf("(%d) %q is not equivalent after the precomputation. expected=%s, got=%s", i, input, expected.Inspect(), actual.Inspect())
+			}
+		}
+	}
+}
+
+func BenchmarkPrecomputeAccessors(b *testing.B) {
+	names := map[string]string{"#s": "status"}
+
+	l := NewLexer("#s = :x AND a = :y")
+	p := NewParser(l)
+	program := p.ParseDynamoExpression()
+
+	env := newOptimizerTestEnvironment(b, map[string]*dynamodb.AttributeValue{
+		"status": {S: aws.String("x")},
+		"a":      {N: aws.String("3")},
+	})
+	env.Aliases = names
+
+	benchmarks := map[string]*DynamoExpression{
+		"generic":  program,
+		"accessor": PrecomputeAccessors(program, names),
+	}
+
+	for name, expr := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				if Eval(expr, env) != TRUE {
+					b.Fatal("expected to be true")
+				}
+			}
+		})
+	}
+}
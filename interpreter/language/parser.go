@@ -1,7 +1,11 @@
 package language
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // Parser represent the interpreter parser
@@ -10,11 +14,31 @@ type Parser struct {
 	curToken  Token
 	peekToken Token
 	errors    []string
+	// parseErrors the errors with their position, in the same order as errors
+	parseErrors []ParseError
+	// lenient accepts the literals not supported by DynamoDB, e.g. true
+	lenient bool
+	// arithmetic accepts the + and - operators, only enabled for the values of the SET actions
+	arithmetic bool
+	// MaxErrors limits the number of errors collected, the rest are only counted. Zero means no limit
+	MaxErrors int
+	// skippedErrors number of errors found after reaching MaxErrors
+	skippedErrors int
+	// MaxDepth limits the nesting of the expressions, e.g. parentheses or NOT operators,
+	// to avoid overflowing the stack. Zero means no limit
+	MaxDepth int
+	// depth current nesting of parseExpression
+	depth int
+	// tooDeep the parsing stopped after exceeding MaxDepth
+	tooDeep bool
 
 	prefixParseFns map[TokenType]prefixParseFn
 	infixParseFns  map[TokenType]infixParseFn
 }
 
+// DefaultMaxDepth default nesting limit of the expressions
+const DefaultMaxDepth = 1000
+
 type (
 	prefixParseFn func() Expression
 	infixParseFn  func(Expression) Expression
@@ -28,46 +52,66 @@ const (
 	precedenceValueNOT               // NOT
 	precedenceValueEqualComparators  // = <>
 	precedenceValueBetweenComparator // BETWEEN
-	precedenceValueComparators       // < <= > >=
+	precedenceValueComparators       // < <= > >= IN
+	precedenceValueSum               // + -
+	precedenceValuePrefix            // -X
 	precedenceValueCall              // myFunction(X)
 )
 
 var precedences = map[TokenType]int{
-	EQ:      precedenceValueEqualComparators,
-	NotEQ:   precedenceValueEqualComparators,
-	BETWEEN: precedenceValueBetweenComparator,
-	LT:      precedenceValueComparators,
-	GT:      precedenceValueComparators,
-	LTE:     precedenceValueComparators,
-	GTE:     precedenceValueComparators,
-	AND:     precedenceValueAND,
-	OR:      precedenceValueOR,
-	LPAREN:  precedenceValueCall,
+	EQ:       precedenceValueEqualComparators,
+	NotEQ:    precedenceValueEqualComparators,
+	BETWEEN:  precedenceValueBetweenComparator,
+	IN:       precedenceValueComparators,
+	LT:       precedenceValueComparators,
+	GT:       precedenceValueComparators,
+	LTE:      precedenceValueComparators,
+	GTE:      precedenceValueComparators,
+	PLUS:     precedenceValueSum,
+	MINUS:    precedenceValueSum,
+	AND:      precedenceValueAND,
+	OR:       precedenceValueOR,
+	LPAREN:   precedenceValueCall,
+	LBRACKET: precedenceValueCall,
+	DOT:      precedenceValueCall,
 }
 
 // NewParser creates a new parser
 func NewParser(l *Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:        l,
+		errors:   []string{},
+		MaxDepth: DefaultMaxDepth,
 	}
 
 	p.prefixParseFns = map[TokenType]prefixParseFn{}
 	p.registerPrefix(IDENT, p.parseIdentifier)
+	p.registerPrefix(ValuePlaceholder, p.parseIdentifier)
+	p.registerPrefix(NamePlaceholder, p.parseIdentifier)
+	p.registerPrefix(BOOL, p.parseBoolLiteral)
+	p.registerPrefix(INT, p.parseNumberLiteral)
+	p.registerPrefix(FLOAT, p.parseNumberLiteral)
+	p.registerPrefix(STRING, p.parseStringLiteral)
 	p.registerPrefix(NOT, p.parsePrefixExpression)
+	p.registerPrefix(MINUS, p.parsePrefixExpression)
 	p.registerPrefix(LPAREN, p.parseGroupedExpression)
 
 	p.infixParseFns = make(map[TokenType]infixParseFn)
 	p.registerInfix(EQ, p.parseInfixExpression)
 	p.registerInfix(NotEQ, p.parseInfixExpression)
 	p.registerInfix(BETWEEN, p.parseBetweenExpression)
+	p.registerInfix(IN, p.parseInExpression)
 	p.registerInfix(LT, p.parseInfixExpression)
 	p.registerInfix(GT, p.parseInfixExpression)
 	p.registerInfix(LTE, p.parseInfixExpression)
 	p.registerInfix(GTE, p.parseInfixExpression)
+	p.registerInfix(PLUS, p.parseInfixExpression)
+	p.registerInfix(MINUS, p.parseInfixExpression)
 	p.registerInfix(AND, p.parseInfixExpression)
 	p.registerInfix(OR, p.parseInfixExpression)
 	p.registerInfix(LPAREN, p.parseCallExpression)
+	p.registerInfix(LBRACKET, p.parseIndexExpression)
+	p.registerInfix(DOT, p.parseDotExpression)
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -76,13 +120,153 @@ func NewParser(l *Lexer) *Parser {
 	return p
 }
 
+// Reset rewinds the parser to parse the input of the lexer, the parse functions and the
+// settings, e.g. MaxErrors or lenient, are kept so the parser can be reused without allocations
+func (p *Parser) Reset(l *Lexer) {
+	p.l = l
+	p.errors = []string{}
+	p.parseErrors = nil
+	p.skippedErrors = 0
+	p.arithmetic = false
+	p.depth = 0
+	p.tooDeep = false
+
+	p.nextToken()
+	p.nextToken()
+}
+
+// NewLenientParser creates a new parser accepting boolean literals as operands
+func NewLenientParser(l *Lexer) *Parser {
+	p := NewParser(l)
+	p.lenient = true
+
+	return p
+}
+
+// Parse parses the condition expression, the errors found are joined and each one can be
+// inspected as a ParseError. Use NewParser to configure the parser, e.g. MaxDepth
+func Parse(input string) (*DynamoExpression, error) {
+	p := NewParser(NewLexer(input))
+	expr := p.ParseDynamoExpression()
+
+	parseErrors := p.ParseErrors()
+	if len(parseErrors) == 0 {
+		return expr, nil
+	}
+
+	errs := make([]error, len(parseErrors))
+	for i, err := range parseErrors {
+		errs[i] = err
+	}
+
+	return nil, errors.Join(errs...)
+}
+
 func (p *Parser) parseIdentifier() Expression {
 	return &Identifier{Token: p.curToken, Value: p.curToken.Literal}
 }
 
-// Errors returns the errors found while parsing
+func (p *Parser) parseBoolLiteral() Expression {
+	if !p.lenient {
+		msg := fmt.Sprintf("boolean literals are only supported in lenient mode, got %s", p.curToken.Literal)
+		p.addError(msg)
+
+		return nil
+	}
+
+	return &BoolLiteral{Token: p.curToken, Value: strings.EqualFold(p.curToken.Literal, "true")}
+}
+
+func (p *Parser) parseNumberLiteral() Expression {
+	if !p.lenient {
+		msg := fmt.Sprintf("number literals are only supported in lenient mode, use an expression attribute value instead of %s", p.curToken.Literal)
+		p.addError(msg)
+
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.addError(fmt.Sprintf("invalid number literal %s", p.curToken.Literal))
+
+		return nil
+	}
+
+	return &NumberLiteral{Token: p.curToken, Value: value}
+}
+
+func (p *Parser) parseStringLiteral() Expression {
+	if !p.lenient {
+		msg := fmt.Sprintf("string literals are only supported in lenient mode, use an expression attribute value instead of %s", p.curToken.Literal)
+		p.addError(msg)
+
+		return nil
+	}
+
+	literal := p.curToken.Literal
+
+	var value strings.Builder
+
+	// the literal includes the quotes, the characters after a backslash are kept as they are
+	for i := 1; i < len(literal)-1; i++ {
+		if literal[i] == '\\' {
+			i++
+		}
+
+		value.WriteByte(literal[i])
+	}
+
+	return &StringLiteral{Token: p.curToken, Value: value.String()}
+}
+
+// Errors returns the errors found while parsing, when MaxErrors is reached the
+// last element summarizes the number of errors not collected
 func (p *Parser) Errors() []string {
-	return p.errors
+	if p.skippedErrors == 0 {
+		return p.errors
+	}
+
+	return append(p.errors[:len(p.errors):len(p.errors)], fmt.Sprintf("... and %d more errors", p.skippedErrors))
+}
+
+// ParseErrors returns the errors found while parsing with their position, the
+// errors skipped after reaching MaxErrors are not included
+func (p *Parser) ParseErrors() []ParseError {
+	return p.parseErrors
+}
+
+// ParseError error found while parsing, Expected and Got are only set when a
+// specific token was expected
+type ParseError struct {
+	Position Position
+	Message  string
+	Expected TokenType
+	Got      TokenType
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Message)
+}
+
+// addError reports the error at the current token
+func (p *Parser) addError(msg string) {
+	p.addParseError(ParseError{Position: p.curToken.Position, Message: msg})
+}
+
+func (p *Parser) addParseError(err ParseError) {
+	// the errors of the unfinished expressions are consequence of the depth error
+	if p.tooDeep {
+		return
+	}
+
+	if p.MaxErrors > 0 && len(p.errors) >= p.MaxErrors {
+		p.skippedErrors++
+
+		return
+	}
+
+	p.errors = append(p.errors, err.Message)
+	p.parseErrors = append(p.parseErrors, err)
 }
 
 func (p *Parser) nextToken() {
@@ -106,6 +290,168 @@ func (p *Parser) ParseDynamoExpression() *DynamoExpression {
 	return program
 }
 
+// ParseUpdateExpression parse the given dynamodb update expression
+func (p *Parser) ParseUpdateExpression() *UpdateExpression {
+	update := &UpdateExpression{}
+	stmt := &UpdateStatement{Token: p.curToken}
+
+	for p.curToken.Type != EOF {
+		if !p.parseUpdateClause(stmt) {
+			return update
+		}
+
+		p.nextToken()
+	}
+
+	if len(stmt.Clauses()) == 0 {
+		p.addError("update expression must have at least one clause")
+
+		return update
+	}
+
+	update.Statement = stmt
+
+	return update
+}
+
+// ParseProjectionExpression parses the comma separated attribute paths of a projection expression
+func (p *Parser) ParseProjectionExpression() *ProjectionExpression {
+	projection := &ProjectionExpression{Token: p.curToken}
+
+	if p.curTokenIs(EOF) {
+		p.addError("projection expression must have at least one attribute path")
+
+		return projection
+	}
+
+	for {
+		path := p.parseUpdatePath()
+		if path == nil {
+			return projection
+		}
+
+		projection.Paths = append(projection.Paths, path)
+
+		p.nextToken()
+
+		switch p.curToken.Type {
+		case EOF:
+			return projection
+		case COMMA:
+			p.nextToken()
+		default:
+			msg := fmt.Sprintf("only attribute paths are allowed in the projection expression, got %s after %s", p.curToken.Literal, path.String())
+			p.addError(msg)
+
+			return projection
+		}
+	}
+}
+
+func (p *Parser) parseUpdateClause(stmt *UpdateStatement) bool {
+	var target **UpdateClause
+
+	switch p.curToken.Type {
+	case SET:
+		target = &stmt.Set
+	case REMOVE:
+		target = &stmt.Remove
+	case ADD:
+		target = &stmt.Add
+	case DELETE:
+		target = &stmt.Delete
+	default:
+		msg := fmt.Sprintf("expected update clause keyword (SET, REMOVE, ADD or DELETE), got %s instead", p.curToken.Type)
+		p.addError(msg)
+
+		return false
+	}
+
+	if *target != nil {
+		msg := fmt.Sprintf("the %s clause can only be used once in an update expression", p.curToken.Type)
+		p.addError(msg)
+
+		return false
+	}
+
+	clause := &UpdateClause{Token: p.curToken}
+
+	for {
+		p.nextToken()
+
+		action := p.parseUpdateAction(clause.Token)
+		if action == nil {
+			return false
+		}
+
+		clause.Actions = append(clause.Actions, action)
+
+		if !p.peekTokenIs(COMMA) {
+			break
+		}
+
+		p.nextToken()
+	}
+
+	*target = clause
+
+	return true
+}
+
+func (p *Parser) parseUpdateAction(clauseToken Token) *UpdateAction {
+	action := &UpdateAction{Token: clauseToken}
+
+	action.Path = p.parseUpdatePath()
+	if action.Path == nil {
+		return nil
+	}
+
+	switch clauseToken.Type {
+	case SET:
+		if !p.expectPeek(EQ) {
+			return nil
+		}
+	case REMOVE:
+		return action
+	}
+
+	p.nextToken()
+
+	// only + and - bind in the values, the comparators are not valid update operands
+	p.arithmetic = clauseToken.Type == SET
+	action.Value = p.parseExpression(precedenceValueComparators)
+	p.arithmetic = false
+
+	if action.Value == nil {
+		return nil
+	}
+
+	return action
+}
+
+func (p *Parser) parseUpdatePath() Expression {
+	if p.curToken.Type != IDENT && p.curToken.Type != NamePlaceholder {
+		msg := fmt.Sprintf("expected attribute path, got %s instead", p.curToken.Type)
+		p.addError(msg)
+
+		return nil
+	}
+
+	path := p.parseIdentifier()
+
+	for path != nil && (p.peekTokenIs(DOT) || p.peekTokenIs(LBRACKET)) {
+		p.nextToken()
+
+		if p.curTokenIs(DOT) {
+			path = p.parseDotExpression(path)
+		} else {
+			path = p.parseIndexExpression(path)
+		}
+	}
+
+	return path
+}
+
 func (p *Parser) parseExpressionStatement() *ExpressionStatement {
 	stmt := &ExpressionStatement{Token: p.curToken}
 	stmt.Expression = p.parseExpression(precedenceValueLowset)
@@ -130,10 +476,51 @@ func (p *Parser) parseGroupedExpression() Expression {
 
 func (p *Parser) noPrefixParseFnError(t TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+
+	switch t {
+	case SET, REMOVE, ADD, DELETE:
+		msg = fmt.Sprintf("%s is only valid in UpdateExpression", t)
+	case ILLEGAL:
+		if strings.HasPrefix(p.curToken.Literal, `"`) {
+			msg = fmt.Sprintf("unterminated string literal %s", p.curToken.Literal)
+		}
+
+		if p.curToken.Literal != "" && isDigit(p.curToken.Literal[0]) {
+			msg = fmt.Sprintf("attribute names can not start with a digit: %s", p.curToken.Literal)
+		}
+
+		if suggestions, ok := operatorTypos[p.curToken.Literal]; ok {
+			msg = fmt.Sprintf("invalid operator %s, did you mean '%s'?", p.curToken.Literal, strings.Join(suggestions, "' or '"))
+		}
+
+		if r, size := utf8.DecodeRuneInString(p.curToken.Literal); size > 1 || r == utf8.RuneError {
+			msg = invalidCharacterMessage(r)
+		}
+	}
+
+	p.addError(msg)
 }
 
 func (p *Parser) parseExpression(precedence int) Expression {
+	if p.tooDeep {
+		return nil
+	}
+
+	p.depth++
+	defer func() { p.depth-- }()
+
+	if p.MaxDepth > 0 && p.depth > p.MaxDepth {
+		p.addError(fmt.Sprintf("the expression exceeds the maximum nesting depth of %d", p.MaxDepth))
+		p.tooDeep = true
+
+		// the rest of the input is skipped
+		for !p.curTokenIs(EOF) {
+			p.nextToken()
+		}
+
+		return nil
+	}
+
 	prefix := p.prefixParseFns[p.curToken.Type]
 
 	if prefix == nil {
@@ -144,7 +531,8 @@ func (p *Parser) parseExpression(precedence int) Expression {
 
 	leftExp := prefix()
 
-	for !p.peekTokenIs(EOF) && precedence < p.peekPrecedence() {
+	// every iteration consumes at least the operator token, the loop always reaches EOF
+	for !p.tooDeep && !p.peekTokenIs(EOF) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
 			return leftExp
@@ -159,21 +547,46 @@ func (p *Parser) parseExpression(precedence int) Expression {
 }
 
 func (p *Parser) parsePrefixExpression() Expression {
+	// the operator is the canonical form of the token, e.g. not => NOT
 	expression := &PrefixExpression{
 		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+		Operator: string(p.curToken.Type),
+	}
+
+	precedence := precedenceValueNOT
+	if p.curTokenIs(MINUS) {
+		p.checkArithmetic()
+
+		precedence = precedenceValuePrefix
 	}
 
 	p.nextToken()
-	expression.Right = p.parseExpression(precedenceValueNOT)
+	expression.Right = p.parseExpression(precedence)
 
 	return expression
 }
 
+// checkArithmetic DynamoDB only supports the arithmetic operators in the SET actions,
+// the lenient parser accepts them in any expression
+func (p *Parser) checkArithmetic() {
+	if !p.arithmetic && !p.lenient {
+		p.addError(fmt.Sprintf("the %s operator is only valid in the SET actions of UpdateExpression", p.curToken.Literal))
+	}
+}
+
 func (p *Parser) parseInfixExpression(left Expression) Expression {
+	if p.curToken.Literal == "!=" && !p.lenient {
+		p.addError("the != operator is not supported, use <> instead")
+	}
+
+	if p.curTokenIs(PLUS) || p.curTokenIs(MINUS) {
+		p.checkArithmetic()
+	}
+
+	// the operator is the canonical form of the token, e.g. != => <>
 	expression := &InfixExpression{
 		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+		Operator: string(p.curToken.Type),
 		Left:     left,
 	}
 
@@ -188,14 +601,107 @@ func (p *Parser) parseInfixExpression(left Expression) Expression {
 	return expression
 }
 
+// updateFunctions the functions only valid in the SET actions of UpdateExpression
+var updateFunctions = map[string]bool{
+	"if_not_exists": true,
+	"list_append":   true,
+}
+
 func (p *Parser) parseCallExpression(function Expression) Expression {
 	exp := &CallExpression{Token: p.curToken, Function: function}
 
+	if identifier, ok := function.(*Identifier); ok && updateFunctions[identifier.Value] && !p.arithmetic && !p.lenient {
+		p.addError(fmt.Sprintf("%s is only valid in UpdateExpression", identifier.Value))
+	}
+
 	exp.Arguments = p.parseCallArguments()
 
+	p.checkFunctionCall(exp)
+
+	return exp
+}
+
+// checkFunctionCall reports the calls to unknown functions or with a wrong number of arguments
+func (p *Parser) checkFunctionCall(exp *CallExpression) {
+	if exp.Function == nil {
+		return
+	}
+
+	identifier, ok := exp.Function.(*Identifier)
+	if !ok {
+		p.addError(fmt.Sprintf("invalid function name; function: %s", exp.Function.String()))
+
+		return
+	}
+
+	arity, ok := LookupFunction(identifier.Value)
+	if !ok {
+		p.addError(fmt.Sprintf("invalid function name; function: %s", identifier.Value))
+
+		return
+	}
+
+	if exp.Arguments != nil && len(exp.Arguments) != arity {
+		msg := fmt.Sprintf("incorrect number of operands for operator or function; operator or function: %s, number of operands: %d", identifier.Value, len(exp.Arguments))
+		p.addError(msg)
+	}
+}
+
+func (p *Parser) parseIndexExpression(left Expression) Expression {
+	exp := &IndexExpression{Token: p.curToken, Left: left}
+
+	if !p.checkPathOperand(left) || !p.expectPeek(INT) {
+		return nil
+	}
+
+	index, err := strconv.Atoi(p.curToken.Literal)
+	if err != nil {
+		msg := fmt.Sprintf("invalid list index %s", p.curToken.Literal)
+		p.addError(msg)
+
+		return nil
+	}
+
+	exp.Index = index
+
+	if !p.expectPeek(RBRACKET) {
+		return nil
+	}
+
 	return exp
 }
 
+func (p *Parser) parseDotExpression(left Expression) Expression {
+	exp := &IndexExpression{Token: p.curToken, Left: left}
+
+	if !p.checkPathOperand(left) {
+		return nil
+	}
+
+	// the map keys can be replaced by name placeholders, e.g. a.#b
+	if p.peekTokenIs(NamePlaceholder) {
+		p.nextToken()
+	} else if !p.expectPeek(IDENT) {
+		return nil
+	}
+
+	exp.Key = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
+func (p *Parser) checkPathOperand(left Expression) bool {
+	switch left.(type) {
+	case *Identifier, *IndexExpression:
+		return true
+	}
+
+	msg := fmt.Sprintf("document path access is only supported on attribute paths, got %s", left.String())
+	p.addError(msg)
+
+	return false
+}
+
 func (p *Parser) parseBetweenExpression(left Expression) Expression {
 	expression := &BetweenExpression{
 		Token: p.curToken,
@@ -203,15 +709,45 @@ func (p *Parser) parseBetweenExpression(left Expression) Expression {
 		Range: [2]Expression{},
 	}
 
+	// the bounds are operands, parsing them above the comparators leaves the AND
+	// separating them and the boolean AND after the upper bound to this function
 	p.nextToken()
-	expression.Range[0] = p.parseIdentifier()
+	expression.Range[0] = p.parseExpression(precedenceValueComparators)
 
-	if !p.expectPeek(AND) {
+	if expression.Range[0] == nil || !p.expectPeek(AND) {
 		return nil
 	}
 
 	p.nextToken()
-	expression.Range[1] = p.parseIdentifier()
+	expression.Range[1] = p.parseExpression(precedenceValueComparators)
+
+	if expression.Range[1] == nil {
+		return nil
+	}
+
+	return expression
+}
+
+func (p *Parser) parseInExpression(left Expression) Expression {
+	expression := &InExpression{
+		Token: p.curToken,
+		Left:  left,
+	}
+
+	if !p.expectPeek(LPAREN) {
+		return nil
+	}
+
+	expression.Candidates = p.parseCallArguments()
+	if expression.Candidates == nil {
+		return nil
+	}
+
+	if len(expression.Candidates) == 0 {
+		p.addError("the IN operator requires at least one operand")
+
+		return nil
+	}
 
 	return expression
 }
@@ -229,6 +765,13 @@ func (p *Parser) parseCallArguments() []Expression {
 
 	for p.peekTokenIs(COMMA) {
 		p.nextToken()
+
+		if p.peekTokenIs(RPAREN) {
+			p.addError("unexpected trailing comma before )")
+
+			return nil
+		}
+
 		p.nextToken()
 		args = append(args, p.parseExpression(precedenceValueLowset))
 	}
@@ -242,6 +785,10 @@ func (p *Parser) parseCallArguments() []Expression {
 
 // helpers
 
+func (p *Parser) curTokenIs(t TokenType) bool {
+	return p.curToken.Type == t
+}
+
 func (p *Parser) peekTokenIs(t TokenType) bool {
 	return p.peekToken.Type == t
 }
@@ -261,7 +808,7 @@ func (p *Parser) expectPeek(t TokenType) bool {
 func (p *Parser) peekError(t TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
 		t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addParseError(ParseError{Position: p.peekToken.Position, Message: msg, Expected: t, Got: p.peekToken.Type})
 }
 
 func (p *Parser) registerPrefix(tokenType TokenType, fn prefixParseFn) {
@@ -1,6 +1,10 @@
 package language
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -133,6 +137,46 @@ func TestParsingBetweenExpression(t *testing.T) {
 	}
 }
 
+func TestParsingInExpression(t *testing.T) {
+	inTests := []struct {
+		input      string
+		left       string
+		candidates []string
+	}{
+		{"a IN (:x)", "a", []string{":x"}},
+		{"#a IN (:x, :y, :z)", "#a", []string{":x", ":y", ":z"}},
+		{"a.b[0] IN (:x, c.d, e[1])", "a.b[0]", []string{":x", "c.d", "e[1]"}},
+	}
+
+	for _, tt := range inTests {
+		program := testParse(t, tt.input)
+
+		stmt, ok := program.Statement.(*ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statement is not ExpressionStatement. got=%T", program.Statement)
+		}
+
+		exp, ok := stmt.Expression.(*InExpression)
+		if !ok {
+			t.Fatalf("exp is not InExpression. got=%T(%s)", stmt.Expression, stmt.Expression)
+		}
+
+		if exp.Left.String() != tt.left {
+			t.Errorf("wrong left operand for %q. expected=%q, got=%q", tt.input, tt.left, exp.Left.String())
+		}
+
+		if len(exp.Candidates) != len(tt.candidates) {
+			t.Fatalf("wrong number of candidates for %q. expected=%d, got=%d", tt.input, len(tt.candidates), len(exp.Candidates))
+		}
+
+		for i, c := range exp.Candidates {
+			if c.String() != tt.candidates[i] {
+				t.Errorf("wrong candidate at %d for %q. expected=%q, got=%q", i, tt.input, tt.candidates[i], c.String())
+			}
+		}
+	}
+}
+
 func testBetweenExpression(t *testing.T, opExp *BetweenExpression, left, min, max interface{}) bool {
 	if !testLiteralExpression(t, opExp.Left, left) {
 		return true
@@ -164,7 +208,27 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		},
 		{
 			"NOT(:a = #a)",
-			"(NOT(:a = #a))",
+			"(NOT (:a = #a))",
+		},
+		{
+			"NOT a = :x AND b = :y",
+			"((NOT (a = :x)) AND (b = :y))",
+		},
+		{
+			"NOT NOT a = :x",
+			"(NOT (NOT (a = :x)))",
+		},
+		{
+			"NOT a = :x OR NOT b BETWEEN :l AND :h",
+			"((NOT (a = :x)) OR (NOT (b BETWEEN :l AND :h)))",
+		},
+		{
+			"NOT (a = :x AND b = :y)",
+			"(NOT ((a = :x) AND (b = :y)))",
+		},
+		{
+			"a = :x AND NOT b IN (:x)",
+			"((a = :x) AND (NOT (b IN (:x))))",
 		},
 		{
 			"a OR b AND c",
@@ -192,7 +256,59 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		},
 		{
 			"NOT :a > size(:s) OR size(:c) = :a",
-			"((NOT(:a > size(:s))) OR (size(:c) = :a))",
+			"((NOT (:a > size(:s))) OR (size(:c) = :a))",
+		},
+		{
+			"items[0] < items[1]",
+			"(items[0] < items[1])",
+		},
+		{
+			"orders[0].total > :t",
+			"(orders[0].total > :t)",
+		},
+		{
+			"a.b[1].c = :x OR size(a.b) > :n",
+			"((a.b[1].c = :x) OR (size(a.b) > :n))",
+		},
+		{
+			"a IN (:x, :y)",
+			"(a IN (:x, :y))",
+		},
+		{
+			"NOT a IN (:x, :y)",
+			"(NOT (a IN (:x, :y)))",
+		},
+		{
+			"NOT a IN (:x) AND b IN (:y, :z) OR c = :x",
+			"(((NOT (a IN (:x))) AND (b IN (:y, :z))) OR (c = :x))",
+		},
+		{
+			"matrix[1][2] = :a AND size(items[3]) > :b",
+			"((matrix[1][2] = :a) AND (size(items[3]) > :b))",
+		},
+		{
+			"size(x) >= :n AND :n < size(y.z)",
+			"((size(x) >= :n) AND (:n < size(y.z)))",
+		},
+		{
+			"a BETWEEN :lo AND :hi AND b = :c",
+			"((a BETWEEN :lo AND :hi) AND (b = :c))",
+		},
+		{
+			"a.b BETWEEN c[0] AND size(d) OR e BETWEEN :x AND :y AND f",
+			"((a.b BETWEEN c[0] AND size(d)) OR ((e BETWEEN :x AND :y) AND f))",
+		},
+		{
+			"(a) = (:b)",
+			"(a = :b)",
+		},
+		{
+			"((((a)))) <> (((:b))) AND (c = :d)",
+			"((a <> :b) AND (c = :d))",
+		},
+		{
+			"(size((a))) > (:n) OR (a) BETWEEN (:x) AND (:y)",
+			"((size(a) > :n) OR (a BETWEEN :x AND :y))",
 		},
 	}
 
@@ -209,6 +325,46 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 	}
 }
 
+func TestParsingDocumentPaths(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []interface{}
+	}{
+		{"profile", []interface{}{"profile"}},
+		{"profile.address.zip", []interface{}{"profile", "address", "zip"}},
+		{"items[2].price", []interface{}{"items", 2, "price"}},
+		{"#p.list[0][1].#k", []interface{}{"#p", "list", 0, 1, "#k"}},
+	}
+
+	for _, tt := range tests {
+		stmt := testParse(t, tt.input).Statement.(*ExpressionStatement)
+
+		segments := []interface{}{}
+
+		for exp := stmt.Expression; exp != nil; {
+			switch node := exp.(type) {
+			case *Identifier:
+				segments = append([]interface{}{node.Value}, segments...)
+				exp = nil
+			case *IndexExpression:
+				if node.Token.Type == DOT {
+					segments = append([]interface{}{node.Key.Value}, segments...)
+				} else {
+					segments = append([]interface{}{node.Index}, segments...)
+				}
+
+				exp = node.Left
+			default:
+				t.Fatalf("unexpected path node %T for %q", node, tt.input)
+			}
+		}
+
+		if !reflect.DeepEqual(segments, tt.expected) {
+			t.Errorf("wrong segments for %q. expected=%v, got=%v", tt.input, tt.expected, segments)
+		}
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "begins_with(:a, #s)"
 	l := NewLexer(input)
@@ -241,120 +397,992 @@ func TestCallExpressionParsing(t *testing.T) {
 	testLiteralExpression(t, exp.Arguments[1], "#s")
 }
 
-func TestParsingErrors(t *testing.T) {
+func TestLenientNotEqualNormalization(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected string
 	}{
-		{
-			"=a",
-			"no prefix parse function for = found",
-		},
-		{
-			"size(a",
-			"expected next token to be ), got EOF instead",
-		},
-		{
-			"b BETWEEN a c",
-			"expected next token to be AND, got IDENT instead",
-		},
+		{"a != :v", "(a <> :v)"},
+		{"a != :v AND b <> :w", "((a <> :v) AND (b <> :w))"},
+		{"NOT a != b", "(NOT (a <> b))"},
+	}
+
+	for _, tt := range tests {
+		l := NewLexer(tt.input)
+		p := NewLenientParser(l)
+		program := p.ParseDynamoExpression()
+		checkParserErrors(t, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestParsingCaseInsensitiveKeywords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a = :v and b = :w", "((a = :v) AND (b = :w))"},
+		{"a = :v Or not b = :w", "((a = :v) OR (NOT (b = :w)))"},
+		{"a between :x And :y", "(a BETWEEN :x AND :y)"},
+		{"a iN (:x, :y)", "(a IN (:x, :y))"},
+		{"And = :v", ""},
 	}
 
 	for _, tt := range tests {
 		l := NewLexer(tt.input)
 		p := NewParser(l)
-		p.ParseDynamoExpression()
+		program := p.ParseDynamoExpression()
 
-		if len(p.errors) == 0 {
-			t.Errorf("no errors found")
-			return
+		if tt.expected == "" {
+			if len(p.Errors()) == 0 {
+				t.Errorf("expected the keyword in %q to be rejected as attribute name", tt.input)
+			}
+
+			continue
 		}
 
-		actual := p.errors[0]
+		checkParserErrors(t, p)
+
+		actual := program.String()
 		if actual != tt.expected {
 			t.Errorf("expected=%q, got=%q", tt.expected, actual)
 		}
 	}
-}
 
-func testIdentifier(t *testing.T, exp Expression, value string) bool {
-	ident, ok := exp.(*Identifier)
-	if !ok {
-		t.Errorf("exp not *Identifier. got=%T", exp)
-		return false
-	}
+	l := NewLexer("set a = :v remove b Add c :n delete d :s")
+	p := NewParser(l)
+	update := p.ParseUpdateExpression()
+	checkParserErrors(t, p)
 
-	if ident.Value != value {
-		t.Errorf("ident.Value not %s. got=%s", value, ident.Value)
-		return false
+	if actual, expected := update.String(), "SET a = :v REMOVE b ADD c :n DELETE d :s"; actual != expected {
+		t.Errorf("expected=%q, got=%q", expected, actual)
 	}
+}
 
-	if ident.TokenLiteral() != value {
-		t.Errorf("ident.TokenLiteral not %s. got=%s", value,
-			ident.TokenLiteral())
-		return false
+func TestParsingLenientLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"age > 21", "(age > 21)"},
+		{"price <= 10.25 AND status = \"ACTIVE\"", "((price <= 10.25) AND (status = \"ACTIVE\"))"},
+		{":x - -1 = a", "((:x - (-1)) = a)"},
+		{`name IN ("a\"b", "c")`, `(name IN ("a\"b", "c"))`},
 	}
 
-	return true
-}
+	for _, tt := range tests {
+		program := testParseLenient(t, tt.input)
 
-func checkParserErrors(t *testing.T, p *Parser) {
-	errors := p.Errors()
-	if len(errors) == 0 {
-		return
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
 	}
 
-	t.Errorf("parser has %d errors", len(errors))
+	program := testParseLenient(t, `a = "say \"hi\" \\o/" OR b = 1.5`)
+	terms := Disjuncts(program.Statement.(*ExpressionStatement).Expression)
 
-	for _, msg := range errors {
-		t.Errorf("parser error: %q", msg)
+	str, ok := terms[0].(*InfixExpression).Right.(*StringLiteral)
+	if !ok || str.Value != `say "hi" \o/` {
+		t.Errorf("wrong string literal value. got=%#v", terms[0].(*InfixExpression).Right)
 	}
 
-	t.FailNow()
+	num, ok := terms[1].(*InfixExpression).Right.(*NumberLiteral)
+	if !ok || num.Value != 1.5 {
+		t.Errorf("wrong number literal value. got=%#v", terms[1].(*InfixExpression).Right)
+	}
 }
 
-func testLiteralExpression(t *testing.T, exp Expression, expected interface{}) bool {
-	v, ok := expected.(string)
-	if ok {
-		return testIdentifier(t, exp, v)
+func TestParseUpdateArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"SET total = total + :inc", "SET total = (total + :inc)"},
+		{"SET #a.#b = :v REMOVE #c[0]", "SET #a.#b = :v REMOVE #c[0]"},
+		{"SET c = c - :d, e = :x - -:y", "SET c = (c - :d), e = (:x - (-:y))"},
+		{"SET a = b - c + :d", "SET a = ((b - c) + :d)"},
+		{"SET a = if_not_exists(a, :z) + :n", "SET a = (if_not_exists(a, :z) + :n)"},
+		{"SET tags = list_append(tags, :newTags)", "SET tags = list_append(tags, :newTags)"},
 	}
 
-	t.Errorf("type of exp not handled. got=%T", exp)
+	for _, tt := range tests {
+		update := testParseUpdate(t, tt.input)
 
-	return false
+		actual := update.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
 }
 
-func testInfixExpression(t *testing.T, exp Expression, left interface{}, operator string, right interface{}) bool {
-	opExp, ok := exp.(*InfixExpression)
-	if !ok {
-		t.Errorf("exp is not OperatorExpression. got=%T(%s)", exp, exp)
-		return false
+func TestLenientArithmeticPrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a + b = c", "((a + b) = c)"},
+		{"a - :x < b AND c", "(((a - :x) < b) AND c)"},
+		{"-a + b", "((-a) + b)"},
 	}
 
-	if !testLiteralExpression(t, opExp.Left, left) {
-		return false
-	}
+	for _, tt := range tests {
+		l := NewLexer(tt.input)
+		p := NewLenientParser(l)
+		program := p.ParseDynamoExpression()
+		checkParserErrors(t, p)
 
-	if opExp.Operator != operator {
-		t.Errorf("exp.Operator is not '%s'. got=%q", operator, opExp.Operator)
-		return false
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
 	}
+}
 
-	if !testLiteralExpression(t, opExp.Right, right) {
-		return false
+func TestParsingPlaceholders(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []Token
+	}{
+		{"price >= :minPrice", []Token{{Type: IDENT, Literal: "price"}, {Type: ValuePlaceholder, Literal: ":minPrice"}}},
+		{"#ts BETWEEN :lo AND :hi", []Token{{Type: NamePlaceholder, Literal: "#ts"}, {Type: ValuePlaceholder, Literal: ":lo"}, {Type: ValuePlaceholder, Literal: ":hi"}}},
+		{"begins_with(#n.#k, :p)", []Token{{Type: IDENT, Literal: "begins_with"}, {Type: NamePlaceholder, Literal: "#n"}, {Type: NamePlaceholder, Literal: "#k"}, {Type: ValuePlaceholder, Literal: ":p"}}},
+		{"#s IN (:a, size(#l))", []Token{{Type: NamePlaceholder, Literal: "#s"}, {Type: ValuePlaceholder, Literal: ":a"}, {Type: IDENT, Literal: "size"}, {Type: NamePlaceholder, Literal: "#l"}}},
 	}
 
-	return true
+	for _, tt := range tests {
+		program := testParse(t, tt.input)
+
+		actual := []Token{}
+
+		Walk(program, func(n Node) bool {
+			if identifier, ok := n.(*Identifier); ok {
+				actual = append(actual, Token{Type: identifier.Token.Type, Literal: identifier.Token.Literal})
+			}
+
+			return true
+		})
+
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("wrong identifiers for %q. expected=%v, got=%v", tt.input, tt.expected, actual)
+		}
+	}
 }
 
-func BenchmarkParser(b *testing.B) {
-	for n := 0; n < b.N; n++ {
-		l := NewLexer(`attribute_exists(:b) AND begins_with(:b, #s) OR #c`)
+func TestParsingOperatorTyposReportOneError(t *testing.T) {
+	for _, input := range []string{"a <>= :b", "a >< :b AND c = :d", "a == :b OR c => :d"} {
+		l := NewLexer(input)
 		p := NewParser(l)
 		p.ParseDynamoExpression()
 
-		if len(p.errors) != 0 {
-			b.Fatal("errors found")
+		typos := 0
+
+		for _, msg := range p.Errors() {
+			if strings.HasPrefix(msg, "invalid operator") {
+				typos++
+			}
+		}
+
+		if typos == 0 || typos != len(p.Errors()) {
+			t.Errorf("expected only the typo errors for %q. got=%q", input, p.Errors())
 		}
 	}
 }
+
+func TestParsingErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"undefined(:a)",
+			"invalid function name; function: undefined",
+		},
+		{
+			"a.b(:a)",
+			"invalid function name; function: a.b",
+		},
+		{
+			"attribute_exists()",
+			"incorrect number of operands for operator or function; operator or function: attribute_exists, number of operands: 0",
+		},
+		{
+			"attribute_not_exists(:a, :x)",
+			"incorrect number of operands for operator or function; operator or function: attribute_not_exists, number of operands: 2",
+		},
+		{
+			"a = :a AND begins_with(:a)",
+			"incorrect number of operands for operator or function; operator or function: begins_with, number of operands: 1",
+		},
+		{
+			"age > 21",
+			"number literals are only supported in lenient mode, use an expression attribute value instead of 21",
+		},
+		{
+			"a = :a OR price = 1.5",
+			"number literals are only supported in lenient mode, use an expression attribute value instead of 1.5",
+		},
+		{
+			`status = "ACTIVE"`,
+			`string literals are only supported in lenient mode, use an expression attribute value instead of "ACTIVE"`,
+		},
+		{
+			`status = "ACTIVE`,
+			`unterminated string literal "ACTIVE`,
+		},
+		{
+			"if_not_exists(a, :d) = :v",
+			"if_not_exists is only valid in UpdateExpression",
+		},
+		{
+			"a = :v OR size(list_append(a, :l)) > :n",
+			"list_append is only valid in UpdateExpression",
+		},
+		{
+			"a = :b \u2212 :c",
+			"invalid character U+2212 '−', did you mean '-'?",
+		},
+		{
+			"a\u00a0= :b",
+			"invalid character U+00A0 '\\u00a0', did you mean ' '?",
+		},
+		{
+			"a <>= :b",
+			"invalid operator <>=, did you mean '<>' or '<='?",
+		},
+		{
+			"a >< :b",
+			"invalid operator ><, did you mean '<>'?",
+		},
+		{
+			"a =< :b AND c = :d",
+			"invalid operator =<, did you mean '<='?",
+		},
+		{
+			"a => :b",
+			"invalid operator =>, did you mean '>='?",
+		},
+		{
+			"a + :b = :c",
+			"the + operator is only valid in the SET actions of UpdateExpression",
+		},
+		{
+			"-a = :b",
+			"the - operator is only valid in the SET actions of UpdateExpression",
+		},
+		{
+			"=a",
+			"no prefix parse function for = found",
+		},
+		{
+			"size(a",
+			"expected next token to be ), got EOF instead",
+		},
+		{
+			"a BETWEEN :x",
+			"expected next token to be AND, got EOF instead",
+		},
+		{
+			"a BETWEEN AND :y",
+			"no prefix parse function for AND found",
+		},
+		{
+			"a BETWEEN :x < :y AND :z",
+			"expected next token to be AND, got < instead",
+		},
+		{
+			"b BETWEEN a c",
+			"expected next token to be AND, got IDENT instead",
+		},
+		{
+			"a[b]",
+			"expected next token to be INT, got IDENT instead",
+		},
+		{
+			"a[0",
+			"expected next token to be ], got EOF instead",
+		},
+		{
+			"a IN ()",
+			"the IN operator requires at least one operand",
+		},
+		{
+			"a IN (:x, :y,)",
+			"unexpected trailing comma before )",
+		},
+		{
+			"a IN :x",
+			"expected next token to be (, got VALUE_PLACEHOLDER instead",
+		},
+		{
+			"size(a)[0]",
+			"document path access is only supported on attribute paths, got size(a)",
+		},
+		{
+			"size(a).b",
+			"document path access is only supported on attribute paths, got size(a)",
+		},
+		{
+			"a.(b)",
+			"expected next token to be IDENT, got ( instead",
+		},
+		{
+			"SET a = :v",
+			"SET is only valid in UpdateExpression",
+		},
+		{
+			"active = true",
+			"boolean literals are only supported in lenient mode, got true",
+		},
+		{
+			"a != :v",
+			"the != operator is not supported, use <> instead",
+		},
+		{
+			"a = :v AND REMOVE b",
+			"REMOVE is only valid in UpdateExpression",
+		},
+		{
+			"1stPlace = :v",
+			"attribute names can not start with a digit: 1stPlace",
+		},
+		{
+			"a = :v AND 2ndPlace = :w",
+			"attribute names can not start with a digit: 2ndPlace",
+		},
+	}
+
+	for _, tt := range tests {
+		l := NewLexer(tt.input)
+		p := NewParser(l)
+		p.ParseDynamoExpression()
+
+		if len(p.errors) == 0 {
+			t.Errorf("no errors found")
+			return
+		}
+
+		actual := p.errors[0]
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []ParseError
+	}{
+		{
+			"a = :v AND size(b",
+			[]ParseError{{
+				Position: Position{Offset: 17, Line: 1, Column: 18},
+				Message:  "expected next token to be ), got EOF instead",
+				Expected: RPAREN,
+				Got:      EOF,
+			}},
+		},
+		{
+			"a = :v\nOR = b",
+			[]ParseError{{
+				Position: Position{Offset: 10, Line: 2, Column: 4},
+				Message:  "no prefix parse function for = found",
+			}},
+		},
+		{
+			"a BETWEEN :x\n  OR :y",
+			[]ParseError{{
+				Position: Position{Offset: 15, Line: 2, Column: 3},
+				Message:  "expected next token to be AND, got OR instead",
+				Expected: AND,
+				Got:      OR,
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		l := NewLexer(tt.input)
+		p := NewParser(l)
+		p.ParseDynamoExpression()
+
+		actual := p.ParseErrors()
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("wrong errors for %q. expected=%+v, got=%+v", tt.input, tt.expected, actual)
+		}
+	}
+
+	err := ParseError{Position: Position{Offset: 3, Line: 1, Column: 4}, Message: "no prefix parse function for = found"}
+	if err.Error() != "line 1, column 4: no prefix parse function for = found" {
+		t.Errorf("wrong error message. got=%q", err.Error())
+	}
+}
+
+func TestParserMaxErrors(t *testing.T) {
+	input := strings.Repeat(") ", 100)
+
+	tests := []struct {
+		maxErrors int
+		expected  []string
+	}{
+		{
+			3,
+			[]string{
+				"no prefix parse function for ) found",
+				"no prefix parse function for ) found",
+				"no prefix parse function for ) found",
+				"... and 97 more errors",
+			},
+		},
+		{
+			100,
+			[]string{},
+		},
+		{
+			0,
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		l := NewLexer(input)
+		p := NewParser(l)
+		p.MaxErrors = tt.maxErrors
+		p.ParseDynamoExpression()
+
+		errs := p.Errors()
+
+		if len(tt.expected) == 0 {
+			if len(errs) != 100 {
+				t.Errorf("expected all the errors with MaxErrors=%d, got=%d", tt.maxErrors, len(errs))
+			}
+
+			continue
+		}
+
+		if strings.Join(errs, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("wrong errors with MaxErrors=%d. expected=%q, got=%q", tt.maxErrors, tt.expected, errs)
+		}
+	}
+}
+
+func testIdentifier(t *testing.T, exp Expression, value string) bool {
+	ident, ok := exp.(*Identifier)
+	if !ok {
+		t.Errorf("exp not *Identifier. got=%T", exp)
+		return false
+	}
+
+	if ident.Value != value {
+		t.Errorf("ident.Value not %s. got=%s", value, ident.Value)
+		return false
+	}
+
+	if ident.TokenLiteral() != value {
+		t.Errorf("ident.TokenLiteral not %s. got=%s", value,
+			ident.TokenLiteral())
+		return false
+	}
+
+	return true
+}
+
+func checkParserErrors(t *testing.T, p *Parser) {
+	errors := p.Errors()
+	if len(errors) == 0 {
+		return
+	}
+
+	t.Errorf("parser has %d errors", len(errors))
+
+	for _, msg := range errors {
+		t.Errorf("parser error: %q", msg)
+	}
+
+	t.FailNow()
+}
+
+func testLiteralExpression(t *testing.T, exp Expression, expected interface{}) bool {
+	v, ok := expected.(string)
+	if ok {
+		return testIdentifier(t, exp, v)
+	}
+
+	t.Errorf("type of exp not handled. got=%T", exp)
+
+	return false
+}
+
+func testInfixExpression(t *testing.T, exp Expression, left interface{}, operator string, right interface{}) bool {
+	opExp, ok := exp.(*InfixExpression)
+	if !ok {
+		t.Errorf("exp is not OperatorExpression. got=%T(%s)", exp, exp)
+		return false
+	}
+
+	if !testLiteralExpression(t, opExp.Left, left) {
+		return false
+	}
+
+	if opExp.Operator != operator {
+		t.Errorf("exp.Operator is not '%s'. got=%q", operator, opExp.Operator)
+		return false
+	}
+
+	if !testLiteralExpression(t, opExp.Right, right) {
+		return false
+	}
+
+	return true
+}
+
+func BenchmarkParser(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		l := NewLexer(`attribute_exists(:b) AND begins_with(:b, #s) OR #c`)
+		p := NewParser(l)
+		p.ParseDynamoExpression()
+
+		if len(p.errors) != 0 {
+			b.Fatal("errors found")
+		}
+	}
+}
+
+func TestParseUpdateExpressionClauseOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"SET b = :v REMOVE a ADD c :n DELETE d :t",
+			"SET b = :v REMOVE a ADD c :n DELETE d :t",
+		},
+		{
+			"REMOVE a SET b = :v ADD c :n DELETE d :t",
+			"SET b = :v REMOVE a ADD c :n DELETE d :t",
+		},
+		{
+			"DELETE d :t ADD c :n REMOVE a SET b = :v",
+			"SET b = :v REMOVE a ADD c :n DELETE d :t",
+		},
+		{
+			"ADD c :n, e :m SET b = :v, f = :w",
+			"SET b = :v, f = :w ADD c :n, e :m",
+		},
+		{
+			"REMOVE a, b, c",
+			"REMOVE a, b, c",
+		},
+		{
+			"REMOVE c.d[1] SET a.b[0] = :v",
+			"SET a.b[0] = :v REMOVE c.d[1]",
+		},
+	}
+
+	for _, tt := range tests {
+		l := NewLexer(tt.input)
+		p := NewParser(l)
+		update := p.ParseUpdateExpression()
+		checkParserErrors(t, p)
+
+		actual := update.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestUpdateExpressionStringRoundTrip(t *testing.T) {
+	inputs := []string{
+		"SET a = :v, #b = c REMOVE d ADD e :n DELETE f :s",
+		"DELETE f :s REMOVE d, g SET a = :v",
+		"SET a.b[0] = :v, a.#c = :w REMOVE d[1].e",
+		"ADD counter :one",
+		"SET a = :v, b = b + :n REMOVE c",
+		"SET a = if_not_exists(a, :z) + :n",
+	}
+
+	for _, input := range inputs {
+		l := NewLexer(input)
+		p := NewParser(l)
+		update := p.ParseUpdateExpression()
+		checkParserErrors(t, p)
+
+		rendered := update.String()
+
+		l = NewLexer(rendered)
+		p = NewParser(l)
+		reparsed := p.ParseUpdateExpression()
+		checkParserErrors(t, p)
+
+		if reparsed.String() != rendered {
+			t.Errorf("the rendered expression changed after parsing it again. expected=%q, got=%q", rendered, reparsed.String())
+		}
+
+		if Hash(update) != Hash(reparsed) {
+			t.Errorf("the clauses of %q are not structurally equal after the round trip", input)
+		}
+	}
+}
+
+func TestParseUpdateExpressionActions(t *testing.T) {
+	input := "REMOVE a SET b = :v ADD c :n DELETE d :t"
+
+	l := NewLexer(input)
+	p := NewParser(l)
+	update := p.ParseUpdateExpression()
+	checkParserErrors(t, p)
+
+	stmt := update.Statement
+
+	tests := []struct {
+		clause *UpdateClause
+		path   string
+		value  string
+	}{
+		{stmt.Set, "b", ":v"},
+		{stmt.Remove, "a", ""},
+		{stmt.Add, "c", ":n"},
+		{stmt.Delete, "d", ":t"},
+	}
+
+	for _, tt := range tests {
+		if tt.clause == nil || len(tt.clause.Actions) != 1 {
+			t.Fatalf("expected one action for %q", tt.path)
+		}
+
+		action := tt.clause.Actions[0]
+		testIdentifier(t, action.Path, tt.path)
+
+		if tt.value == "" {
+			if action.Value != nil {
+				t.Errorf("unexpected value for %q. got=%s", tt.path, action.Value)
+			}
+
+			continue
+		}
+
+		testIdentifier(t, action.Value, tt.value)
+	}
+}
+
+func TestParseUpdateExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"SET a = :a SET b = :b",
+			"the SET clause can only be used once in an update expression",
+		},
+		{
+			"REMOVE a ADD b :b REMOVE c",
+			"the REMOVE clause can only be used once in an update expression",
+		},
+		{
+			"a = :a",
+			"expected update clause keyword (SET, REMOVE, ADD or DELETE), got IDENT instead",
+		},
+		{
+			"SET a :a",
+			"expected next token to be =, got VALUE_PLACEHOLDER instead",
+		},
+		{
+			"SET a = :a,",
+			"expected attribute path, got EOF instead",
+		},
+		{
+			"SET :v = a",
+			"expected attribute path, got VALUE_PLACEHOLDER instead",
+		},
+		{
+			"SET a.:v = b",
+			"expected next token to be IDENT, got VALUE_PLACEHOLDER instead",
+		},
+		{
+			"SET a = list_append(a)",
+			"incorrect number of operands for operator or function; operator or function: list_append, number of operands: 1",
+		},
+		{
+			"ADD a if_not_exists(a, :z)",
+			"if_not_exists is only valid in UpdateExpression",
+		},
+		{
+			"ADD a :x + :y",
+			"the + operator is only valid in the SET actions of UpdateExpression",
+		},
+		{
+			"SET a = :v < :w",
+			"expected update clause keyword (SET, REMOVE, ADD or DELETE), got < instead",
+		},
+		{
+			"SET a = :v IN (:w)",
+			"expected update clause keyword (SET, REMOVE, ADD or DELETE), got IN instead",
+		},
+		{
+			"SET a = :v + :w = :x",
+			"expected update clause keyword (SET, REMOVE, ADD or DELETE), got = instead",
+		},
+		{
+			"SET a = :x DELETE b :s - :y",
+			"the - operator is only valid in the SET actions of UpdateExpression",
+		},
+		{
+			"",
+			"update expression must have at least one clause",
+		},
+	}
+
+	for _, tt := range tests {
+		l := NewLexer(tt.input)
+		p := NewParser(l)
+		p.ParseUpdateExpression()
+
+		if len(p.errors) == 0 {
+			t.Errorf("no errors found for %q", tt.input)
+			continue
+		}
+
+		actual := p.errors[0]
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestParseProjectionExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"id", []string{"id"}},
+		{"id, profile.name, items[0]", []string{"id", "profile.name", "items[0]"}},
+		{"#n, #p.#k[1].total", []string{"#n", "#p.#k[1].total"}},
+	}
+
+	for _, tt := range tests {
+		l := NewLexer(tt.input)
+		p := NewParser(l)
+		projection := p.ParseProjectionExpression()
+		checkParserErrors(t, p)
+
+		actual := []string{}
+		for _, path := range projection.Paths {
+			actual = append(actual, path.String())
+		}
+
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("wrong paths for %q. expected=%v, got=%v", tt.input, tt.expected, actual)
+		}
+
+		if projection.String() != strings.Join(tt.expected, ", ") {
+			t.Errorf("wrong string for %q. got=%q", tt.input, projection.String())
+		}
+	}
+}
+
+func TestParseProjectionExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"",
+			"projection expression must have at least one attribute path",
+		},
+		{
+			"a = :v",
+			"only attribute paths are allowed in the projection expression, got = after a",
+		},
+		{
+			"id, size(tags)",
+			"only attribute paths are allowed in the projection expression, got ( after size",
+		},
+		{
+			"a.b AND c",
+			"only attribute paths are allowed in the projection expression, got AND after a.b",
+		},
+		{
+			"id, :v",
+			"expected attribute path, got VALUE_PLACEHOLDER instead",
+		},
+		{
+			"id,",
+			"expected attribute path, got EOF instead",
+		},
+	}
+
+	for _, tt := range tests {
+		l := NewLexer(tt.input)
+		p := NewParser(l)
+		p.ParseProjectionExpression()
+
+		if len(p.errors) == 0 {
+			t.Errorf("no errors found for %q", tt.input)
+			continue
+		}
+
+		actual := p.errors[0]
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestParserMaxDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxDepth int
+		errors   int
+	}{
+		{strings.Repeat("(", 100000) + "a", DefaultMaxDepth, 1},
+		{strings.Repeat("NOT ", 100000) + "a", DefaultMaxDepth, 1},
+		{strings.Repeat("(", 500) + "a" + strings.Repeat(")", 500), DefaultMaxDepth, 0},
+		{strings.Repeat("(", 10) + "a" + strings.Repeat(")", 10), 5, 1},
+		{strings.Repeat("(", 5000) + "a" + strings.Repeat(")", 5000), 0, 0},
+	}
+
+	for _, tt := range tests {
+		l := NewLexer(tt.input)
+		p := NewParser(l)
+		p.MaxDepth = tt.maxDepth
+		p.ParseDynamoExpression()
+
+		if len(p.Errors()) != tt.errors {
+			t.Fatalf("wrong number of errors with MaxDepth=%d. expected=%d, got=%d", tt.maxDepth, tt.errors, len(p.Errors()))
+		}
+
+		if tt.errors > 0 {
+			expected := fmt.Sprintf("the expression exceeds the maximum nesting depth of %d", tt.maxDepth)
+			if p.Errors()[0] != expected {
+				t.Errorf("expected=%q, got=%q", expected, p.Errors()[0])
+			}
+		}
+	}
+
+	l := NewLexer("SET a = " + strings.Repeat("(", 100000) + ":v")
+	p := NewParser(l)
+	p.ParseUpdateExpression()
+
+	if len(p.Errors()) != 1 {
+		t.Errorf("expected only the depth error for the update expression. got=%v", p.Errors())
+	}
+}
+
+func TestParsingUnterminatedExpressions(t *testing.T) {
+	inputs := []string{
+		"(a = :b",
+		"((a",
+		"a IN (:x, :y",
+		"size(a",
+		"a BETWEEN :x",
+		"a[0",
+		"a.",
+		"NOT",
+		"a = :b AND",
+	}
+
+	for _, input := range inputs {
+		l := NewLexer(input)
+		p := NewParser(l)
+		p.ParseDynamoExpression()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected errors for %q", input)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	expr, err := Parse("a = :a AND size(b) > :n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expr.String() != "((a = :a) AND (size(b) > :n))" {
+		t.Errorf("wrong expression. got=%q", expr.String())
+	}
+
+	expr, err = Parse("a = AND unknown(b)")
+	if err == nil {
+		t.Fatalf("expected an error, got expression %q", expr.String())
+	}
+
+	if expr != nil {
+		t.Errorf("expected no expression for a malformed input. got=%q", expr.String())
+	}
+
+	var parseErr ParseError
+	if !errors.As(err, &parseErr) || parseErr.Position.Column != 5 {
+		t.Errorf("expected the first error at column 5. got=%+v", parseErr)
+	}
+
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 2 {
+		t.Errorf("expected 2 joined errors. got=%q", err.Error())
+	}
+}
+
+func TestParserReset(t *testing.T) {
+	p := NewLenientParser(NewLexer("a = :a AND"))
+	p.MaxErrors = 1
+	p.ParseDynamoExpression()
+
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected errors for the first input")
+	}
+
+	inputs := []string{"a = :a AND b <> :b", "active = true", "size(a) > :n"}
+
+	for _, input := range inputs {
+		p.Reset(NewLexer(input))
+		program := p.ParseDynamoExpression()
+		checkParserErrors(t, p)
+
+		expected := testParseLenient(t, input).String()
+		if program.String() != expected {
+			t.Errorf("wrong program after reset. expected=%q, got=%q", expected, program.String())
+		}
+	}
+
+	p.Reset(NewLexer("a = :a AND undefined(:b) AND undefined(:c)"))
+	p.ParseDynamoExpression()
+
+	if len(p.Errors()) != 2 || p.MaxErrors != 1 {
+		t.Errorf("expected the settings to be kept after reset. got=%v", p.Errors())
+	}
+}
+
+func testParseLenient(t *testing.T, input string) *DynamoExpression {
+	p := NewLenientParser(NewLexer(input))
+	program := p.ParseDynamoExpression()
+	checkParserErrors(t, p)
+
+	return program
+}
+
+func BenchmarkParseDynamoExpression(b *testing.B) {
+	input := "attribute_exists(#a) AND (b BETWEEN :x AND :y OR c IN (:x, :y)) AND size(d.e[0]) > :n"
+
+	b.Run("NewParser", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			p := NewParser(NewLexer(input))
+			p.ParseDynamoExpression()
+		}
+	})
+
+	b.Run("Reset", func(b *testing.B) {
+		b.ReportAllocs()
+
+		p := NewParser(NewLexer(input))
+
+		for n := 0; n < b.N; n++ {
+			p.Reset(NewLexer(input))
+			p.ParseDynamoExpression()
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package language
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// UndefinedPlaceholder returns the first expression attribute name (#name) or value (:value)
+// used by the expression that is not defined in the maps, the maps can be nil
+func UndefinedPlaceholder(n Node, names map[string]*string, values map[string]*dynamodb.AttributeValue) (string, bool) {
+	var undefined string
+
+	Walk(n, func(node Node) bool {
+		if undefined != "" {
+			return false
+		}
+
+		identifier, ok := node.(*Identifier)
+		if !ok {
+			return true
+		}
+
+		switch {
+		case strings.HasPrefix(identifier.Value, ":"):
+			if _, ok := values[identifier.Value]; !ok {
+				undefined = identifier.Value
+			}
+		case strings.HasPrefix(identifier.Value, "#"):
+			if _, ok := names[identifier.Value]; !ok {
+				undefined = identifier.Value
+			}
+		}
+
+		return true
+	})
+
+	return undefined, undefined != ""
+}
@@ -0,0 +1,42 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestUndefinedPlaceholder(t *testing.T) {
+	names := map[string]*string{"#n": aws.String("name")}
+	values := map[string]*dynamodb.AttributeValue{":x": {S: aws.String("x")}}
+
+	tests := []struct {
+		input     string
+		names     map[string]*string
+		values    map[string]*dynamodb.AttributeValue
+		undefined string
+	}{
+		{"a = b AND attribute_exists(c.d[0])", nil, nil, ""},
+		{"#n = :x", names, values, ""},
+		{"#n = :x", nil, values, "#n"},
+		{"#n = :x", names, nil, ":x"},
+		{"a.#n = :x", map[string]*string{}, values, "#n"},
+		{"a = :x OR size(b) > :missing", names, values, ":missing"},
+		{"a IN (:x, :y)", nil, values, ":y"},
+	}
+
+	for _, tt := range tests {
+		undefined, ok := UndefinedPlaceholder(testParse(t, tt.input), tt.names, tt.values)
+		if undefined != tt.undefined || ok != (tt.undefined != "") {
+			t.Errorf("wrong undefined placeholder for %q. expected=%q, got=%q", tt.input, tt.undefined, undefined)
+		}
+	}
+
+	update := testParseUpdate(t, "SET #n = :x REMOVE #other")
+
+	undefined, _ := UndefinedPlaceholder(update, names, values)
+	if undefined != "#other" {
+		t.Errorf("wrong undefined placeholder for the update. expected=%q, got=%q", "#other", undefined)
+	}
+}
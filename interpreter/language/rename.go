@@ -0,0 +1,88 @@
+package language
+
+import "strings"
+
+// RenameAttributes returns a copy of the expression where the top level attribute of every
+// document path is renamed according to the renames map, e.g. a.b[0] with a => c is c.b[0].
+// The placeholders are never renamed, the ExpressionAttributeNames must be renamed instead
+func RenameAttributes(expr *DynamoExpression, renames map[string]string) *DynamoExpression {
+	stmt, ok := expr.Statement.(*ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return expr
+	}
+
+	return &DynamoExpression{
+		Statement: &ExpressionStatement{
+			Token:      stmt.Token,
+			Expression: renameExpression(stmt.Expression, renames),
+		},
+	}
+}
+
+func renameExpression(exp Expression, renames map[string]string) Expression {
+	switch node := exp.(type) {
+	case *Identifier:
+		name, ok := renames[node.Value]
+		if !ok || strings.HasPrefix(node.Value, ":") || strings.HasPrefix(node.Value, "#") {
+			return exp
+		}
+
+		return &Identifier{Token: Token{Type: IDENT, Literal: name}, Value: name}
+	case *IndexExpression:
+		return &IndexExpression{
+			Token: node.Token,
+			Left:  renameExpression(node.Left, renames),
+			Index: node.Index,
+			Key:   node.Key,
+		}
+	case *PrefixExpression:
+		return &PrefixExpression{
+			Token:    node.Token,
+			Operator: node.Operator,
+			Right:    renameExpression(node.Right, renames),
+		}
+	case *InfixExpression:
+		return &InfixExpression{
+			Token:    node.Token,
+			Operator: node.Operator,
+			Left:     renameExpression(node.Left, renames),
+			Right:    renameExpression(node.Right, renames),
+		}
+	case *BetweenExpression:
+		return &BetweenExpression{
+			Token: node.Token,
+			Left:  renameExpression(node.Left, renames),
+			Range: [2]Expression{renameExpression(node.Range[0], renames), renameExpression(node.Range[1], renames)},
+		}
+	case *InExpression:
+		return &InExpression{
+			Token:      node.Token,
+			Left:       renameExpression(node.Left, renames),
+			Candidates: renameExpressions(node.Candidates, renames),
+			values:     node.values,
+		}
+	case *CallExpression:
+		// the function name is not an attribute
+		return &CallExpression{
+			Token:     node.Token,
+			Function:  node.Function,
+			Arguments: renameExpressions(node.Arguments, renames),
+		}
+	case *sharedExpression:
+		return renameExpression(node.Expression, renames)
+	case *attributeAccessor:
+		return renameExpression(node.Identifier, renames)
+	}
+
+	return exp
+}
+
+func renameExpressions(list []Expression, renames map[string]string) []Expression {
+	renamed := make([]Expression, len(list))
+
+	for i, exp := range list {
+		renamed[i] = renameExpression(exp, renames)
+	}
+
+	return renamed
+}
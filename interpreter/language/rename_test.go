@@ -0,0 +1,73 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestRenameAttributes(t *testing.T) {
+	renames := map[string]string{
+		"status": "state",
+		"price":  "cost",
+		"size":   "dimensions",
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"status = :s", "(state = :s)"},
+		{"status = :s AND price BETWEEN :lo AND :hi", "((state = :s) AND (cost BETWEEN :lo AND :hi))"},
+		{"NOT status IN (:a, :b) OR attribute_exists(price.amount)", "((NOT (state IN (:a, :b))) OR attribute_exists(cost.amount))"},
+		{"size(price.status[0]) > :n", "(size(cost.status[0]) > :n)"},
+		{"size(size) > :n AND begins_with(other, :p)", "((size(dimensions) > :n) AND begins_with(other, :p))"},
+		{"#status = :s AND other.price = status", "((#status = :s) AND (other.price = state))"},
+	}
+
+	for _, tt := range tests {
+		program := testParse(t, tt.input)
+		original := program.String()
+
+		actual := RenameAttributes(program, renames).String()
+		if actual != tt.expected {
+			t.Errorf("wrong renamed expression for %q. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+
+		if program.String() != original {
+			t.Errorf("the original expression was modified. got=%q", program.String())
+		}
+	}
+}
+
+func TestRenameAttributesEvaluation(t *testing.T) {
+	program := testParse(t, "status = :s AND price < :max OR attribute_not_exists(price)")
+	renamed := RenameAttributes(program, map[string]string{"status": "state", "price": "cost"})
+
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"state": {S: aws.String("active")},
+		"cost":  {N: aws.String("5")},
+		":s":    {S: aws.String("active")},
+		":max":  {N: aws.String("10")},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	if Eval(renamed, env) != TRUE {
+		t.Errorf("expected the renamed expression to match the migrated item")
+	}
+
+	if Eval(program, env) != TRUE {
+		t.Errorf("expected the original expression to match because price does not exist")
+	}
+
+	env.Set("price", &Number{Value: 50})
+
+	if Eval(program, env) != FALSE {
+		t.Errorf("expected the original expression to use the old attributes")
+	}
+}
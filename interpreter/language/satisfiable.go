@@ -0,0 +1,160 @@
+package language
+
+// pathConstraint the requirements of the AND terms over a document path
+type pathConstraint struct {
+	// types allowed for the path, nil when any type is allowed
+	types   map[ObjectType]bool
+	present bool
+	absent  bool
+}
+
+var (
+	comparatorOperandTypes = []ObjectType{ObjectTypeNumber, ObjectTypeString, ObjectTypeBinary}
+	beginsWithOperandTypes = []ObjectType{ObjectTypeString, ObjectTypeBinary}
+	containsOperandTypes   = []ObjectType{
+		ObjectTypeString, ObjectTypeStringSet, ObjectTypeNumberSet, ObjectTypeBinarySet, ObjectTypeList,
+	}
+	sizeOperandTypes = []ObjectType{
+		ObjectTypeString, ObjectTypeBinary, ObjectTypeList, ObjectTypeMap,
+		ObjectTypeStringSet, ObjectTypeNumberSet, ObjectTypeBinarySet,
+	}
+)
+
+// IsSatisfiable reports whether an item could match the condition, it returns false when
+// the AND terms have contradictory requirements over the same path, e.g.
+// attribute_not_exists(a) AND begins_with(a, :p). A nested path requires its parents to
+// exist as maps or lists, e.g. a.b requires a map in a. The terms under OR and NOT are not
+// analyzed, so a true result does not guarantee that an item can match
+func IsSatisfiable(expr *DynamoExpression) bool {
+	stmt, ok := expr.Statement.(*ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return true
+	}
+
+	constraints := map[string]*pathConstraint{}
+
+	for _, term := range Conjuncts(stmt.Expression) {
+		addTermConstraints(term, constraints)
+	}
+
+	for _, c := range constraints {
+		if c.absent && (c.present || c.types != nil) {
+			return false
+		}
+
+		if c.types != nil && len(c.types) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func addTermConstraints(term Expression, constraints map[string]*pathConstraint) {
+	switch node := term.(type) {
+	case *InfixExpression:
+		switch node.Operator {
+		case LT, LTE, GT, GTE:
+			constrainOperand(node.Left, constraints, comparatorOperandTypes)
+			constrainOperand(node.Right, constraints, comparatorOperandTypes)
+		case EQ:
+			constrainOperand(node.Left, constraints, nil)
+			constrainOperand(node.Right, constraints, nil)
+		}
+	case *BetweenExpression:
+		constrainOperand(node.Left, constraints, comparatorOperandTypes)
+	case *InExpression:
+		constrainOperand(node.Left, constraints, nil)
+	case *CallExpression:
+		addCallConstraints(node, constraints)
+	}
+}
+
+func addCallConstraints(node *CallExpression, constraints map[string]*pathConstraint) {
+	if len(node.Arguments) == 0 {
+		return
+	}
+
+	path := node.Arguments[0]
+
+	switch node.Function.String() {
+	case "attribute_exists":
+		requirePath(path, constraints)
+	case "attribute_not_exists":
+		constrainPath(path, constraints).absent = true
+	case "begins_with":
+		constrainOperand(path, constraints, beginsWithOperandTypes)
+	case "contains":
+		constrainOperand(path, constraints, containsOperandTypes)
+	}
+}
+
+// constrainOperand requires the path operand to exist with one of the types, the
+// arguments of size are constrained too, e.g. size(a) > :n
+func constrainOperand(operand Expression, constraints map[string]*pathConstraint, types []ObjectType) {
+	if call, ok := operand.(*CallExpression); ok && call.Function.String() == "size" && len(call.Arguments) == 1 {
+		constrainOperand(call.Arguments[0], constraints, sizeOperandTypes)
+
+		return
+	}
+
+	c := requirePath(operand, constraints)
+	if types != nil {
+		c.restrictTypes(types)
+	}
+}
+
+// requirePath requires the path to exist, the parents of a nested path must exist as a map
+// for the '.' access or as a list for the '[' access
+func requirePath(operand Expression, constraints map[string]*pathConstraint) *pathConstraint {
+	c := constrainPath(operand, constraints)
+	c.present = true
+
+	if index, ok := operand.(*IndexExpression); ok {
+		parentType := ObjectTypeList
+		if index.Key != nil {
+			parentType = ObjectTypeMap
+		}
+
+		requirePath(index.Left, constraints).restrictTypes([]ObjectType{parentType})
+	}
+
+	return c
+}
+
+// restrictTypes keeps the allowed types of the constraint that are in types
+func (c *pathConstraint) restrictTypes(types []ObjectType) {
+	allowed := map[ObjectType]bool{}
+
+	for _, typ := range types {
+		if c.types == nil || c.types[typ] {
+			allowed[typ] = true
+		}
+	}
+
+	c.types = allowed
+}
+
+// constrainPath returns the constraint of the path, the placeholders and the other
+// operands get a detached constraint that is not analyzed
+func constrainPath(operand Expression, constraints map[string]*pathConstraint) *pathConstraint {
+	switch operand.(type) {
+	case *Identifier, *IndexExpression:
+	default:
+		return &pathConstraint{}
+	}
+
+	if identifier, ok := operand.(*Identifier); ok && len(identifier.Value) > 0 && identifier.Value[0] == ':' {
+		return &pathConstraint{}
+	}
+
+	name := operand.String()
+
+	c, ok := constraints[name]
+	if !ok {
+		c = &pathConstraint{}
+		constraints[name] = c
+	}
+
+	return c
+}
@@ -0,0 +1,37 @@
+package language
+
+import "testing"
+
+func TestIsSatisfiable(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"a = :x AND begins_with(b, :p)", true},
+		{"begins_with(a, :p) AND a > :x AND contains(a, :s)", true},
+		{"attribute_exists(a) AND attribute_not_exists(a)", false},
+		{"attribute_not_exists(a) AND begins_with(a, :p)", false},
+		{"attribute_not_exists(a.b) AND a.b = :x", false},
+		{"attribute_not_exists(a) AND a.b = :x", false},
+		{"attribute_not_exists(a.c) AND a.b = :x", true},
+		{"begins_with(a, :p) AND a.b = :x", false},
+		{"a.b = :x AND a[0] = :y", false},
+		{"size(a) > :n AND attribute_exists(a[1].b)", true},
+		{"a > :x AND contains(a[0].b, :s)", false},
+		{"size(a) > :n AND a BETWEEN :x AND :y", true},
+		{"contains(a, :s) AND begins_with(a, :p) AND a < :x", true},
+		{"attribute_not_exists(a) AND size(a) > :n", false},
+		{"attribute_not_exists(a) OR a = :x", true},
+		{"NOT attribute_exists(a) AND a = :x", true},
+		{":x < :y AND attribute_not_exists(:x)", true},
+	}
+
+	for _, tt := range tests {
+		program := testParse(t, tt.input)
+
+		actual := IsSatisfiable(program)
+		if actual != tt.expected {
+			t.Errorf("wrong result for %q. expected=%t, got=%t", tt.input, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package language
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSchemaViolation when an expression uses an attribute in a way its declared type does not support
+var ErrSchemaViolation = errors.New("the expression is not compatible with the schema")
+
+// AttributeType declared type of an attribute in a schema, e.g. ObjectTypeString
+type AttributeType = ObjectType
+
+var (
+	scalarTypes = map[AttributeType]bool{ObjectTypeString: true, ObjectTypeNumber: true, ObjectTypeBinary: true}
+	sizedTypes  = map[AttributeType]bool{
+		ObjectTypeString: true, ObjectTypeBinary: true, ObjectTypeList: true, ObjectTypeMap: true,
+		ObjectTypeStringSet: true, ObjectTypeNumberSet: true, ObjectTypeBinarySet: true,
+	}
+	containerTypes = map[AttributeType]bool{
+		ObjectTypeString: true, ObjectTypeList: true,
+		ObjectTypeStringSet: true, ObjectTypeNumberSet: true, ObjectTypeBinarySet: true,
+	}
+	addTypes = map[AttributeType]bool{
+		ObjectTypeNumber: true, ObjectTypeStringSet: true, ObjectTypeNumberSet: true, ObjectTypeBinarySet: true,
+	}
+)
+
+// ValidateAgainstSchema checks that the paths declared in the schema are used as their types
+// allow, e.g. a string can not be used in arithmetic. The paths are matched by their text,
+// e.g. address.city or #n, and the paths missing in the schema are not checked
+func ValidateAgainstSchema(expr Node, schema map[string]AttributeType) error {
+	var err error
+
+	Walk(expr, func(n Node) bool {
+		if err != nil {
+			return false
+		}
+
+		err = validateNodeSchema(n, schema)
+
+		return err == nil
+	})
+
+	return err
+}
+
+func validateNodeSchema(n Node, schema map[string]AttributeType) error {
+	switch node := n.(type) {
+	case *InfixExpression:
+		return validateInfixSchema(node, schema)
+	case *BetweenExpression:
+		return checkOperandTypes(BETWEEN, scalarTypes, schema, node.Left, node.Range[0], node.Range[1])
+	case *IndexExpression:
+		if node.Token.Type == DOT {
+			return checkOperandTypes(".", map[AttributeType]bool{ObjectTypeMap: true}, schema, node.Left)
+		}
+
+		return checkOperandTypes("[]", map[AttributeType]bool{ObjectTypeList: true}, schema, node.Left)
+	case *CallExpression:
+		return validateCallSchema(node, schema)
+	case *UpdateAction:
+		switch node.Token.Type {
+		case ADD:
+			return checkOperandTypes(ADD, addTypes, schema, node.Path)
+		case DELETE:
+			return checkOperandTypes(DELETE, setTypes, schema, node.Path)
+		}
+	}
+
+	return nil
+}
+
+func validateInfixSchema(node *InfixExpression, schema map[string]AttributeType) error {
+	switch {
+	case node.Operator == PLUS || node.Operator == MINUS:
+		return checkOperandTypes(node.Operator, map[AttributeType]bool{ObjectTypeNumber: true}, schema, node.Left, node.Right)
+	case isOrdering(node.Operator):
+		if err := checkOperandTypes(node.Operator, scalarTypes, schema, node.Left, node.Right); err != nil {
+			return err
+		}
+
+		left, leftOk := schemaType(node.Left, schema)
+		right, rightOk := schemaType(node.Right, schema)
+
+		if leftOk && rightOk && left != right {
+			return fmt.Errorf("%w: type mismatch for %s; operator: %s, operand types: %s and %s", ErrSchemaViolation, node.String(), node.Operator, left, right)
+		}
+	}
+
+	return nil
+}
+
+func validateCallSchema(node *CallExpression, schema map[string]AttributeType) error {
+	if node.Function == nil || len(node.Arguments) == 0 {
+		return nil
+	}
+
+	name := node.Function.String()
+
+	switch name {
+	case "size":
+		return checkOperandTypes(name, sizedTypes, schema, node.Arguments[0])
+	case "begins_with":
+		return checkOperandTypes(name, map[AttributeType]bool{ObjectTypeString: true, ObjectTypeBinary: true}, schema, node.Arguments[0])
+	case "contains":
+		return checkOperandTypes(name, containerTypes, schema, node.Arguments[0])
+	case "list_append":
+		return checkOperandTypes(name, map[AttributeType]bool{ObjectTypeList: true}, schema, node.Arguments...)
+	}
+
+	return nil
+}
+
+// checkOperandTypes fails when the declared type of an operand is not one of the allowed types
+func checkOperandTypes(operator string, allowed map[AttributeType]bool, schema map[string]AttributeType, operands ...Expression) error {
+	for _, operand := range operands {
+		declared, ok := schemaType(operand, schema)
+		if ok && !allowed[declared] {
+			return fmt.Errorf("%w: incorrect operand type for operator or function; operator or function: %s, operand type: %s, path: %s",
+				ErrSchemaViolation, operator, declared, operand.String())
+		}
+	}
+
+	return nil
+}
+
+// schemaType returns the type of the operand when it is known, the paths use the declared type
+func schemaType(operand Expression, schema map[string]AttributeType) (AttributeType, bool) {
+	switch node := operand.(type) {
+	case *Identifier, *IndexExpression:
+		declared, ok := schema[node.String()]
+
+		return declared, ok
+	case *NumberLiteral:
+		return ObjectTypeNumber, true
+	case *StringLiteral:
+		return ObjectTypeString, true
+	case *CallExpression:
+		if node.Function != nil && node.Function.String() == "size" {
+			return ObjectTypeNumber, true
+		}
+	}
+
+	return "", false
+}
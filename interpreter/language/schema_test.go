@@ -0,0 +1,81 @@
+package language
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateAgainstSchema(t *testing.T) {
+	schema := map[string]AttributeType{
+		"name":        ObjectTypeString,
+		"views":       ObjectTypeNumber,
+		"active":      ObjectTypeBoolean,
+		"tags":        ObjectTypeStringSet,
+		"history":     ObjectTypeList,
+		"address":     ObjectTypeMap,
+		"address.zip": ObjectTypeNumber,
+		"history[0]":  ObjectTypeString,
+		"#n":          ObjectTypeString,
+	}
+
+	conditions := []struct {
+		input    string
+		expected string
+	}{
+		{"name = :name AND views > :min", ""},
+		{"begins_with(#n, :prefix) AND size(tags) > :size", ""},
+		{"address.zip BETWEEN :lo AND :hi AND contains(history, :event)", ""},
+		{"history[0] < :event AND undeclared > :v", ""},
+		{"active > :v", "incorrect operand type for operator or function; operator or function: >, operand type: BOOL, path: active"},
+		{"name < views", "type mismatch for (name < views); operator: <, operand types: S and N"},
+		{"size(views) > :size", "incorrect operand type for operator or function; operator or function: size, operand type: N, path: views"},
+		{"begins_with(tags, :prefix)", "incorrect operand type for operator or function; operator or function: begins_with, operand type: SS, path: tags"},
+		{"contains(address, :v)", "incorrect operand type for operator or function; operator or function: contains, operand type: M, path: address"},
+		{"name.first = :v", "incorrect operand type for operator or function; operator or function: ., operand type: S, path: name"},
+		{"address[0] = :v", "incorrect operand type for operator or function; operator or function: [], operand type: M, path: address"},
+		{"name BETWEEN :lo AND active", "incorrect operand type for operator or function; operator or function: BETWEEN, operand type: BOOL, path: active"},
+	}
+
+	for _, tt := range conditions {
+		err := ValidateAgainstSchema(testParse(t, tt.input), schema)
+		checkSchemaError(t, tt.input, err, tt.expected)
+	}
+
+	updates := []struct {
+		input    string
+		expected string
+	}{
+		{"SET views = views + :one, history = list_append(history, :events) ADD tags :tags", ""},
+		{"SET name = :name REMOVE address.zip DELETE tags :tags", ""},
+		{"SET views = name + :one", "incorrect operand type for operator or function; operator or function: +, operand type: S, path: name"},
+		{"SET views = :one - size(name)", ""},
+		{"SET history = list_append(:events, name)", "incorrect operand type for operator or function; operator or function: list_append, operand type: S, path: name"},
+		{"ADD name :suffix", "incorrect operand type for operator or function; operator or function: ADD, operand type: S, path: name"},
+		{"DELETE views :one", "incorrect operand type for operator or function; operator or function: DELETE, operand type: N, path: views"},
+	}
+
+	for _, tt := range updates {
+		err := ValidateAgainstSchema(testParseUpdate(t, tt.input), schema)
+		checkSchemaError(t, tt.input, err, tt.expected)
+	}
+}
+
+func checkSchemaError(t *testing.T, input string, err error, expected string) {
+	t.Helper()
+
+	if expected == "" {
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", input, err)
+		}
+
+		return
+	}
+
+	if !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("expected a schema violation for %q, got=%v", input, err)
+	}
+
+	if err.Error() != ErrSchemaViolation.Error()+": "+expected {
+		t.Errorf("wrong error for %q. got=%q, want=%q", input, err.Error(), expected)
+	}
+}
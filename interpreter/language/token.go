@@ -1,5 +1,10 @@
 package language
 
+import (
+	"fmt"
+	"strings"
+)
+
 // TokenType represents the type of the token
 type TokenType string
 
@@ -7,6 +12,22 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	// Position where the token starts in the input, it is empty for the tokens built by hand
+	Position Position
+}
+
+// Position location in the expression input
+type Position struct {
+	// Offset byte offset starting at 0
+	Offset int
+	// Line starting at 1
+	Line int
+	// Column byte offset in the line starting at 1
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
 }
 
 const (
@@ -17,6 +38,18 @@ const (
 
 	// IDENT identifier operand or function
 	IDENT TokenType = "IDENT"
+	// INT unsigned integer used by the list indexes and the number literals
+	INT TokenType = "INT"
+	// FLOAT decimal number literal, e.g. 1.5, only supported by the lenient parser
+	FLOAT TokenType = "FLOAT"
+	// STRING double quoted string literal, e.g. "ACTIVE", only supported by the lenient parser
+	STRING TokenType = "STRING"
+	// BOOL boolean literal, only supported by the lenient parser
+	BOOL TokenType = "BOOL"
+	// ValuePlaceholder expression attribute value, e.g. :minPrice
+	ValuePlaceholder TokenType = "VALUE_PLACEHOLDER"
+	// NamePlaceholder expression attribute name, e.g. #ts
+	NamePlaceholder TokenType = "NAME_PLACEHOLDER"
 
 	// LT logical comparator less than
 	LT = "<"
@@ -31,6 +64,11 @@ const (
 	// NotEQ logical comparator not equal
 	NotEQ = "<>"
 
+	// PLUS arithmetic addition, only valid in the SET actions
+	PLUS = "+"
+	// MINUS arithmetic subtraction or negation, only valid in the SET actions
+	MINUS = "-"
+
 	// COMMA delimiter used with IN keyword
 	COMMA TokenType = ","
 
@@ -39,6 +77,13 @@ const (
 	// RPAREN right parentheses delimiter
 	RPAREN TokenType = ")"
 
+	// DOT delimiter used by map attributes paths
+	DOT TokenType = "."
+	// LBRACKET left bracket delimiter used by list indexes
+	LBRACKET TokenType = "["
+	// RBRACKET right bracket delimiter used by list indexes
+	RBRACKET TokenType = "]"
+
 	// AND logical evaluation keyword
 	AND = "AND"
 	// OR logical evaluation keyword
@@ -49,6 +94,15 @@ const (
 	BETWEEN = "BETWEEN"
 	// IN compare operand against list of values
 	IN = "IN"
+
+	// SET update clause keyword
+	SET = "SET"
+	// REMOVE update clause keyword
+	REMOVE = "REMOVE"
+	// ADD update clause keyword
+	ADD = "ADD"
+	// DELETE update clause keyword
+	DELETE = "DELETE"
 )
 
 var keywords = map[string]TokenType{
@@ -57,13 +111,34 @@ var keywords = map[string]TokenType{
 	"NOT":     NOT,
 	"BETWEEN": BETWEEN,
 	"IN":      IN,
+	"SET":     SET,
+	"REMOVE":  REMOVE,
+	"ADD":     ADD,
+	"DELETE":  DELETE,
 }
 
-// LookupIdent checks if the ident is a keyword
+var placeholderPrefixes = map[byte]TokenType{
+	':': ValuePlaceholder,
+	'#': NamePlaceholder,
+}
+
+// LookupIdent checks if the ident is a keyword or a placeholder, the keywords are case insensitive
 func LookupIdent(ident string) TokenType {
-	if tok, ok := keywords[ident]; ok {
+	if tok, ok := placeholderPrefixes[ident[0]]; ok {
+		if len(ident) == 1 {
+			return ILLEGAL
+		}
+
+		return tok
+	}
+
+	if tok, ok := keywords[strings.ToUpper(ident)]; ok {
 		return tok
 	}
 
+	if strings.EqualFold(ident, "true") || strings.EqualFold(ident, "false") {
+		return BOOL
+	}
+
 	return IDENT
 }
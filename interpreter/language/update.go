@@ -0,0 +1,273 @@
+package language
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ErrOverlappingPaths when two update actions target overlapping document paths
+var ErrOverlappingPaths = errors.New("two document paths overlap with each other")
+
+// ErrKeyUpdate when an update action targets a primary key attribute
+var ErrKeyUpdate = errors.New("one or more parameter values were invalid")
+
+// ErrUnsupportedSDKUpdate when an update action can not be converted into the AttributeUpdates of the AWS SDK
+var ErrUnsupportedSDKUpdate = errors.New("the update action can not be represented as an AttributeValueUpdate")
+
+// MergeUpdates combines the actions of both update expressions per clause, the
+// actions of a are placed before the ones of b. It fails when both expressions
+// modify overlapping document paths, e.g. SET a = :a and REMOVE a.b
+func MergeUpdates(a, b *UpdateExpression) (*UpdateExpression, error) {
+	left := updateStatementOf(a)
+	right := updateStatementOf(b)
+
+	for _, pathA := range updatePaths(left) {
+		for _, pathB := range updatePaths(right) {
+			if pathsOverlap(pathA, pathB) {
+				return nil, fmt.Errorf("%w: %s and %s", ErrOverlappingPaths, pathA, pathB)
+			}
+		}
+	}
+
+	stmt := &UpdateStatement{
+		Token:  left.Token,
+		Set:    mergeClauses(left.Set, right.Set),
+		Remove: mergeClauses(left.Remove, right.Remove),
+		Add:    mergeClauses(left.Add, right.Add),
+		Delete: mergeClauses(left.Delete, right.Delete),
+	}
+
+	if len(left.Clauses()) == 0 {
+		stmt.Token = right.Token
+	}
+
+	return &UpdateExpression{Statement: stmt}, nil
+}
+
+func updateStatementOf(update *UpdateExpression) *UpdateStatement {
+	if update == nil || update.Statement == nil {
+		return &UpdateStatement{}
+	}
+
+	return update.Statement
+}
+
+func mergeClauses(a, b *UpdateClause) *UpdateClause {
+	if a == nil && b == nil {
+		return nil
+	}
+
+	clause := &UpdateClause{}
+
+	for _, c := range []*UpdateClause{a, b} {
+		if c == nil {
+			continue
+		}
+
+		clause.Token = c.Token
+		clause.Actions = append(clause.Actions, c.Actions...)
+	}
+
+	return clause
+}
+
+func updatePaths(stmt *UpdateStatement) []string {
+	paths := []string{}
+
+	for _, clause := range stmt.Clauses() {
+		for _, action := range clause.Actions {
+			paths = append(paths, action.Path.String())
+		}
+	}
+
+	return paths
+}
+
+// pathsOverlap whether a path is equal to or a parent of the other, e.g. a and a.b[0]
+func pathsOverlap(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	if !strings.HasPrefix(b, a) {
+		return false
+	}
+
+	return len(a) == len(b) || b[len(a)] == '.' || b[len(a)] == '['
+}
+
+// ValidateUpdateNotKeys fails when an action of the update expression modifies the partition key
+// or the sort key, the names are the expression attribute names used to resolve the aliases,
+// e.g. #pk. The sort key is ignored when empty
+func ValidateUpdateNotKeys(update *UpdateExpression, names map[string]*string, pk, sk string) error {
+	aliases := nameAliases(names)
+
+	for _, clause := range updateStatementOf(update).Clauses() {
+		for _, action := range clause.Actions {
+			root := rootIdentifier(action.Path)
+			if root == nil {
+				continue
+			}
+
+			name := root.Value
+			if alias, ok := aliases[name]; ok {
+				name = alias
+			}
+
+			if name == pk || (sk != "" && name == sk) {
+				return fmt.Errorf("%w: Cannot update attribute %s. This attribute is part of the key", ErrKeyUpdate, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExplainUpdate describes each action of the update expression in plain terms, one per
+// line in the order they are applied, e.g. set attribute 'views' to ':one'
+func ExplainUpdate(update *UpdateExpression) string {
+	lines := []string{}
+
+	for _, clause := range updateStatementOf(update).Clauses() {
+		for _, action := range clause.Actions {
+			lines = append(lines, explainUpdateAction(action))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// IsNoOp whether applying the update to the item would leave it unchanged, e.g. SET a = a or
+// ADD counter :zero. The updates that fail are not no-ops because the error must be reported
+func IsNoOp(update *UpdateExpression, item map[string]*dynamodb.AttributeValue, names map[string]*string, values map[string]*dynamodb.AttributeValue) bool {
+	if update == nil {
+		return false
+	}
+
+	env, err := newConditionEnvironment(item, values, nameAliases(names))
+	if err != nil {
+		return false
+	}
+
+	env.Lenient = false
+
+	if isError(EvalUpdate(update, env)) {
+		return false
+	}
+
+	attributes := 0
+
+	for name, val := range env.store {
+		if _, ok := values[name]; ok {
+			continue
+		}
+
+		original, ok := item[name]
+		if !ok {
+			return false
+		}
+
+		obj, err := MapToObject(original)
+		if err != nil || !equalObject(obj, val) {
+			return false
+		}
+
+		attributes++
+	}
+
+	return attributes == len(item)
+}
+
+// ToSDKUpdate converts the update expression into the AttributeUpdates of the AWS SDK, the
+// attribute names and the values are resolved with the expression attribute names and values.
+// SET actions are PUT and REMOVE actions are DELETE without a value. It fails with
+// ErrUnsupportedSDKUpdate for the actions that the AttributeUpdates can not represent, e.g.
+// nested paths, arithmetic or functions
+func ToSDKUpdate(update *UpdateExpression, names map[string]*string, values map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValueUpdate, error) {
+	aliases := nameAliases(names)
+	updates := map[string]*dynamodb.AttributeValueUpdate{}
+
+	for _, clause := range updateStatementOf(update).Clauses() {
+		for _, action := range clause.Actions {
+			name, err := sdkUpdateName(action.Path, aliases)
+			if err != nil {
+				return nil, err
+			}
+
+			attrUpdate := &dynamodb.AttributeValueUpdate{}
+
+			switch action.Token.Type {
+			case SET:
+				attrUpdate.Action = aws.String(dynamodb.AttributeActionPut)
+			case ADD:
+				attrUpdate.Action = aws.String(dynamodb.AttributeActionAdd)
+			case REMOVE, DELETE:
+				attrUpdate.Action = aws.String(dynamodb.AttributeActionDelete)
+			}
+
+			if action.Value != nil {
+				attrUpdate.Value, err = sdkUpdateValue(action.Value, values)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			updates[name] = attrUpdate
+		}
+	}
+
+	return updates, nil
+}
+
+func sdkUpdateName(path Expression, aliases map[string]string) (string, error) {
+	identifier, ok := path.(*Identifier)
+	if !ok {
+		return "", fmt.Errorf("%w: nested path %s", ErrUnsupportedSDKUpdate, path.String())
+	}
+
+	if !strings.HasPrefix(identifier.Value, "#") {
+		return identifier.Value, nil
+	}
+
+	name, ok := aliases[identifier.Value]
+	if !ok {
+		return "", fmt.Errorf("%w: undefined attribute name %s", ErrUnsupportedSDKUpdate, identifier.Value)
+	}
+
+	return name, nil
+}
+
+func sdkUpdateValue(value Expression, values map[string]*dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+	identifier, ok := value.(*Identifier)
+	if !ok || !strings.HasPrefix(identifier.Value, ":") {
+		return nil, fmt.Errorf("%w: value %s is not an expression attribute value", ErrUnsupportedSDKUpdate, value.String())
+	}
+
+	val, ok := values[identifier.Value]
+	if !ok {
+		return nil, fmt.Errorf("%w: undefined attribute value %s", ErrUnsupportedSDKUpdate, identifier.Value)
+	}
+
+	return val, nil
+}
+
+func explainUpdateAction(action *UpdateAction) string {
+	path := action.Path.String()
+
+	switch action.Token.Type {
+	case SET:
+		return fmt.Sprintf("set attribute '%s' to '%s'", path, action.Value.String())
+	case REMOVE:
+		return fmt.Sprintf("remove attribute '%s'", path)
+	case ADD:
+		return fmt.Sprintf("add '%s' to attribute '%s'", action.Value.String(), path)
+	case DELETE:
+		return fmt.Sprintf("delete the elements of '%s' from attribute '%s'", action.Value.String(), path)
+	}
+
+	return fmt.Sprintf("unknown action %s on attribute '%s'", action.Token.Literal, path)
+}
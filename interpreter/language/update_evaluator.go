@@ -0,0 +1,481 @@
+package language
+
+import (
+	"sort"
+	"strconv"
+)
+
+// maxNestingDepth nesting levels of the documents supported by DynamoDB
+const maxNestingDepth = 32
+
+// EvalUpdate runs the update expression in the environment
+func EvalUpdate(n Node, env *Environment) Object {
+	switch node := n.(type) {
+	case *UpdateExpression:
+		return EvalUpdate(node.Statement, env)
+	case *UpdateStatement:
+		if node == nil {
+			return newError("empty update expression")
+		}
+
+		return evalUpdateStatement(node, env)
+	}
+
+	return newError("unsupported update expression: %s", n.String())
+}
+
+type updateChange struct {
+	path Expression
+	// value is NULL when the attribute must be removed
+	value Object
+}
+
+func evalUpdateStatement(node *UpdateStatement, env *Environment) Object {
+	changes := []updateChange{}
+	paths := []string{}
+
+	// all the actions are evaluated against the original item before applying them
+	for _, clause := range node.Clauses() {
+		for _, action := range clause.Actions {
+			name, ok := updatePathName(action.Path, env)
+			if !ok {
+				return newError("unsupported update path: %s", action.Path.String())
+			}
+
+			for _, path := range paths {
+				if pathsOverlap(path, name) {
+					return newError("two document paths overlap with each other: %s", name)
+				}
+			}
+
+			paths = append(paths, name)
+
+			_, topLevel := action.Path.(*Identifier)
+			if !topLevel && (action.Token.Type == ADD || action.Token.Type == DELETE) {
+				return newError("the %s action only supports top level attributes: %s", action.Token.Literal, name)
+			}
+
+			val := evalUpdateAction(action, env)
+			if isError(val) {
+				return val
+			}
+
+			changes = append(changes, updateChange{path: action.Path, value: val})
+		}
+	}
+
+	sortRemovals(changes, env)
+
+	for _, change := range changes {
+		result := applyUpdateChange(change, env)
+		if isError(result) {
+			return result
+		}
+	}
+
+	for _, change := range changes {
+		name := env.ResolveName(rootIdentifier(change.path).Value)
+
+		if val, ok := env.Get(name); ok && nestingDepth(val) > maxNestingDepth {
+			return newError("nesting levels have exceeded supported limits: %s", name)
+		}
+	}
+
+	return NULL
+}
+
+// sortRemovals moves the removals after the other changes, the list elements are removed from
+// the highest index to the lowest so the indexes keep pointing to the elements of the original item,
+// e.g. REMOVE l[0], l[1] on [a, b, c] leaves [c]
+func sortRemovals(changes []updateChange, env *Environment) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		removeI := isUndefined(changes[i].value)
+		removeJ := isUndefined(changes[j].value)
+
+		if removeI != removeJ {
+			return removeJ
+		}
+
+		if !removeI {
+			return false
+		}
+
+		return removedBefore(pathParts(changes[i].path, env), pathParts(changes[j].path, env))
+	})
+}
+
+// pathPart is a map key or a list index of a document path
+type pathPart struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// pathParts returns the parts of the path with the aliases resolved
+func pathParts(path Expression, env *Environment) []pathPart {
+	switch p := path.(type) {
+	case *Identifier:
+		return []pathPart{{key: env.ResolveName(p.Value)}}
+	case *IndexExpression:
+		if p.Token.Type == DOT {
+			return append(pathParts(p.Left, env), pathPart{key: env.ResolveName(p.Key.Value)})
+		}
+
+		return append(pathParts(p.Left, env), pathPart{index: p.Index, isIndex: true})
+	}
+
+	return nil
+}
+
+// removedBefore orders the removed paths with the list indexes in descending order
+func removedBefore(a, b []pathPart) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch {
+		case a[i].isIndex != b[i].isIndex:
+			return a[i].isIndex
+		case a[i].isIndex && a[i].index != b[i].index:
+			return a[i].index > b[i].index
+		case !a[i].isIndex && a[i].key != b[i].key:
+			return a[i].key < b[i].key
+		}
+	}
+
+	return len(a) > len(b)
+}
+
+// nestingDepth returns the number of nested documents, e.g. 0 for a scalar and 2 for {"a": [1]}
+func nestingDepth(obj Object) int {
+	depth := 0
+
+	switch o := obj.(type) {
+	case *Map:
+		for _, v := range o.Value {
+			if d := nestingDepth(v); d > depth {
+				depth = d
+			}
+		}
+	case *List:
+		for _, v := range o.Value {
+			if d := nestingDepth(v); d > depth {
+				depth = d
+			}
+		}
+	default:
+		return 0
+	}
+
+	return depth + 1
+}
+
+// updatePathName returns the document path with the aliases resolved, e.g. #a.b[0] => attr.b[0]
+func updatePathName(path Expression, env *Environment) (string, bool) {
+	switch p := path.(type) {
+	case *Identifier:
+		return env.ResolveName(p.Value), true
+	case *IndexExpression:
+		left, ok := updatePathName(p.Left, env)
+		if !ok {
+			return "", false
+		}
+
+		if p.Token.Type == DOT {
+			return left + "." + env.ResolveName(p.Key.Value), true
+		}
+
+		return left + "[" + strconv.Itoa(p.Index) + "]", true
+	}
+
+	return "", false
+}
+
+func applyUpdateChange(change updateChange, env *Environment) Object {
+	switch path := change.path.(type) {
+	case *Identifier:
+		name := env.ResolveName(path.Value)
+
+		if isUndefined(change.value) {
+			env.Remove(name)
+		} else {
+			env.Set(name, change.value)
+		}
+
+		return NULL
+	case *IndexExpression:
+		return applyNestedUpdateChange(path, change.value, env)
+	}
+
+	return newError("unsupported update path: %s", change.path.String())
+}
+
+// applyNestedUpdateChange replaces the top level attribute with a copy before
+// modifying it, the documents of the original item are never modified
+func applyNestedUpdateChange(path *IndexExpression, val Object, env *Environment) Object {
+	root := rootIdentifier(path)
+
+	name := env.ResolveName(root.Value)
+
+	current, ok := env.Get(name)
+	if !ok {
+		return newError("the document path provided in the update expression is invalid for update: %s", path.String())
+	}
+
+	env.Set(name, copyObject(current))
+
+	parent := Eval(path.Left, env)
+
+	switch p := parent.(type) {
+	case *Map:
+		if path.Token.Type != DOT {
+			break
+		}
+
+		key := env.ResolveName(path.Key.Value)
+
+		if isUndefined(val) {
+			delete(p.Value, key)
+		} else {
+			p.Value[key] = val
+		}
+
+		return NULL
+	case *List:
+		if path.Token.Type != LBRACKET {
+			break
+		}
+
+		updateListElement(p, path.Index, val)
+
+		return NULL
+	}
+
+	return newError("the document path provided in the update expression is invalid for update: %s", path.String())
+}
+
+// updateListElement replaces the element in the position, the value is appended
+// when the position is after the end of the list
+func updateListElement(list *List, pos int, val Object) {
+	if isUndefined(val) {
+		if pos < len(list.Value) {
+			list.Value = append(list.Value[:pos], list.Value[pos+1:]...)
+		}
+
+		return
+	}
+
+	if pos < len(list.Value) {
+		list.Value[pos] = val
+
+		return
+	}
+
+	list.Value = append(list.Value, val)
+}
+
+func rootIdentifier(path Expression) *Identifier {
+	for {
+		switch p := path.(type) {
+		case *Identifier:
+			return p
+		case *IndexExpression:
+			path = p.Left
+		default:
+			return nil
+		}
+	}
+}
+
+func evalUpdateAction(action *UpdateAction, env *Environment) Object {
+	if action.Token.Type == REMOVE {
+		return NULL
+	}
+
+	val := Eval(action.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	if isUndefined(val) {
+		return newError("the provided expression refers to an attribute that does not exist in the item: %s", action.Value.String())
+	}
+
+	switch action.Token.Type {
+	case SET:
+		return val
+	case ADD:
+		return evalAddAction(Eval(action.Path, env), val)
+	case DELETE:
+		return evalDeleteAction(Eval(action.Path, env), val)
+	}
+
+	return newError("unsupported update action: %s", action.Token.Literal)
+}
+
+func evalAddAction(current, val Object) Object {
+	switch val.Type() {
+	case ObjectTypeNumber:
+		if isUndefined(current) {
+			return val
+		}
+
+		if current.Type() != ObjectTypeNumber {
+			return newError("an operand in the update expression has an incorrect data type: ADD %s %s", current.Type(), val.Type())
+		}
+
+		return addNumbers(current.(*Number), val.(*Number))
+	case ObjectTypeStringSet, ObjectTypeNumberSet, ObjectTypeBinarySet:
+		if isUndefined(current) {
+			return copySet(val)
+		}
+
+		if current.Type() != val.Type() {
+			return newError("an operand in the update expression has an incorrect data type: ADD %s %s", current.Type(), val.Type())
+		}
+
+		return unionSets(current, val)
+	}
+
+	return newError("an operand in the update expression has an incorrect data type: ADD operand must be a number or a set, got %s", val.Type())
+}
+
+func evalDeleteAction(current, val Object) Object {
+	if !setTypes[val.Type()] {
+		return newError("an operand in the update expression has an incorrect data type: DELETE operand must be a set, got %s", val.Type())
+	}
+
+	if isUndefined(current) {
+		return NULL
+	}
+
+	if current.Type() != val.Type() {
+		return newError("an operand in the update expression has an incorrect data type: DELETE %s %s", current.Type(), val.Type())
+	}
+
+	return differenceSets(current, val)
+}
+
+// copyObject returns a deep copy of the documents and sets, the scalar objects are immutable
+func copyObject(obj Object) Object {
+	switch o := obj.(type) {
+	case *Map:
+		m := &Map{Value: make(map[string]Object, len(o.Value))}
+
+		for k, v := range o.Value {
+			m.Value[k] = copyObject(v)
+		}
+
+		return m
+	case *List:
+		l := &List{Value: make([]Object, len(o.Value))}
+
+		for i, v := range o.Value {
+			l.Value[i] = copyObject(v)
+		}
+
+		return l
+	case *StringSet, *NumberSet, *BinarySet:
+		return copySet(o)
+	}
+
+	return obj
+}
+
+func copySet(set Object) Object {
+	switch s := set.(type) {
+	case *StringSet:
+		return unionSets(&StringSet{Value: map[string]bool{}}, s)
+	case *NumberSet:
+		return unionSets(&NumberSet{Value: map[float64]bool{}}, s)
+	case *BinarySet:
+		return unionSets(&BinarySet{}, s)
+	}
+
+	return newError("unsupported set type %s", set.Type())
+}
+
+func unionSets(left, right Object) Object {
+	switch l := left.(type) {
+	case *StringSet:
+		union := &StringSet{Value: map[string]bool{}}
+
+		for _, set := range []*StringSet{l, right.(*StringSet)} {
+			for str := range set.Value {
+				union.Value[str] = true
+			}
+		}
+
+		return union
+	case *NumberSet:
+		union := &NumberSet{Value: map[float64]bool{}}
+
+		for _, set := range []*NumberSet{l, right.(*NumberSet)} {
+			for n := range set.Value {
+				union.Value[n] = true
+			}
+		}
+
+		return union
+	case *BinarySet:
+		union := &BinarySet{Value: [][]byte{}}
+
+		for _, set := range []*BinarySet{l, right.(*BinarySet)} {
+			for _, bin := range set.Value {
+				if !containedInBinaryArray(union.Value, bin) {
+					union.Value = append(union.Value, bin)
+				}
+			}
+		}
+
+		return union
+	}
+
+	return newError("unsupported set type %s", left.Type())
+}
+
+// differenceSets returns the elements of left not present in right, the result
+// is NULL when no element remains because DynamoDB does not allow empty sets
+func differenceSets(left, right Object) Object {
+	var size int
+
+	result := left
+
+	switch l := left.(type) {
+	case *StringSet:
+		diff := &StringSet{Value: map[string]bool{}}
+
+		for str := range l.Value {
+			if !right.(*StringSet).Value[str] {
+				diff.Value[str] = true
+			}
+		}
+
+		result, size = diff, len(diff.Value)
+	case *NumberSet:
+		diff := &NumberSet{Value: map[float64]bool{}}
+
+		for n := range l.Value {
+			if !right.(*NumberSet).Value[n] {
+				diff.Value[n] = true
+			}
+		}
+
+		result, size = diff, len(diff.Value)
+	case *BinarySet:
+		diff := &BinarySet{Value: [][]byte{}}
+
+		for _, bin := range l.Value {
+			if !containedInBinaryArray(right.(*BinarySet).Value, bin) {
+				diff.Value = append(diff.Value, bin)
+			}
+		}
+
+		result, size = diff, len(diff.Value)
+	default:
+		return newError("unsupported set type %s", left.Type())
+	}
+
+	if size == 0 {
+		return NULL
+	}
+
+	return result
+}
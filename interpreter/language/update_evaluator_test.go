@@ -0,0 +1,455 @@
+package language
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestEvalUpdateAddSet(t *testing.T) {
+	tests := []struct {
+		input     string
+		attribute string
+		expected  string
+	}{
+		{"ADD tags :newTags", "tags", "[ a b ]<SS>"},
+		{"ADD colors :newTags", "colors", "[ a b blue red ]<SS>"},
+		{"ADD numbers :newNumbers", "numbers", "[ 1 2 ]<NS>"},
+		{"ADD scores :newNumbers", "scores", "[ 1 2 10 ]<NS>"},
+		{"ADD counter :num", "counter", "1.000000"},
+		{"ADD total :num", "total", "11.000000"},
+	}
+
+	for _, tt := range tests {
+		env := newUpdateTestEnvironment(t)
+
+		evaluated := testEvalUpdate(t, tt.input, env)
+		if isError(evaluated) {
+			t.Fatalf("unexpected error for %q: %s", tt.input, evaluated.Inspect())
+		}
+
+		val, ok := env.Get(tt.attribute)
+		if !ok {
+			t.Fatalf("attribute %q not found after %q", tt.attribute, tt.input)
+		}
+
+		if val.Inspect() != tt.expected {
+			t.Errorf("wrong value for %q. got=%s, want=%s", tt.input, val.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestEvalUpdateAddBinarySet(t *testing.T) {
+	env := newUpdateTestEnvironment(t)
+
+	evaluated := testEvalUpdate(t, "ADD binaries :newBinaries, data :newBinaries", env)
+	if isError(evaluated) {
+		t.Fatalf("unexpected error: %s", evaluated.Inspect())
+	}
+
+	binaries, _ := env.Get("binaries")
+	if binaries.Inspect() != "[ [97] [98] ]<BS>" {
+		t.Errorf("wrong value for the missing set. got=%s", binaries.Inspect())
+	}
+
+	data, _ := env.Get("data")
+	if data.Inspect() != "[ [99] [97] [98] ]<BS>" {
+		t.Errorf("wrong value for the existing set. got=%s", data.Inspect())
+	}
+}
+
+func TestEvalUpdateActions(t *testing.T) {
+	env := newUpdateTestEnvironment(t)
+
+	evaluated := testEvalUpdate(t, "SET total = :num, copy = total REMOVE data DELETE colors :colors, scores :newNumbers", env)
+	if isError(evaluated) {
+		t.Fatalf("unexpected error: %s", evaluated.Inspect())
+	}
+
+	expected := map[string]string{
+		"total":  "1.000000",
+		"copy":   "10.000000",
+		"scores": "[ 10 ]<NS>",
+	}
+
+	for name, val := range expected {
+		obj, ok := env.Get(name)
+		if !ok {
+			t.Fatalf("attribute %q not found", name)
+		}
+
+		if obj.Inspect() != val {
+			t.Errorf("wrong value for %q. got=%s, want=%s", name, obj.Inspect(), val)
+		}
+	}
+
+	for _, name := range []string{"data", "colors"} {
+		if _, ok := env.Get(name); ok {
+			t.Errorf("attribute %q should be removed", name)
+		}
+	}
+}
+
+func TestEvalUpdateErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{
+			"ADD colors :newNumbers",
+			"an operand in the update expression has an incorrect data type: ADD SS NS",
+		},
+		{
+			"ADD total :newTags",
+			"an operand in the update expression has an incorrect data type: ADD N SS",
+		},
+		{
+			"ADD colors :str",
+			"an operand in the update expression has an incorrect data type: ADD operand must be a number or a set, got S",
+		},
+		{
+			"ADD colors :notFound",
+			"the provided expression refers to an attribute that does not exist in the item: :notFound",
+		},
+		{
+			"ADD total :num, total :num",
+			"two document paths overlap with each other: total",
+		},
+		{
+			"SET items = list_append(items, :str)",
+			"an operand in the update expression has an incorrect data type: list_append(L, S)",
+		},
+		{
+			"SET items = list_append(colors, items)",
+			"an operand in the update expression has an incorrect data type: list_append(SS, L)",
+		},
+		{
+			"SET items = list_append(missing, :events)",
+			"the provided expression refers to an attribute that does not exist in the item",
+		},
+	}
+
+	for _, tt := range tests {
+		env := newUpdateTestEnvironment(t)
+		evaluated := testEvalUpdate(t, tt.input, env)
+
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("no error object returned for %s. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %s. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestEvalUpdateNestedPaths(t *testing.T) {
+	tests := []struct {
+		input    string
+		path     string
+		expected string
+	}{
+		{"SET profile.name = :str", "profile.name", "txt"},
+		{"SET profile.name = :str", "profile.age", "30.000000"},
+		{"REMOVE profile.age", "profile", "{}"},
+		{"SET #p.age = :num", "profile.age", "1.000000"},
+		{"SET items[1] = :str", "items", "[ 10.000000<N> txt<S> ]"},
+		{"SET items[5] = :str", "items", "[ 10.000000<N> 20.000000<N> txt<S> ]"},
+		{"REMOVE items[0]", "items", "[ 20.000000<N> ]"},
+		{"REMOVE items[7]", "items", "[ 10.000000<N> 20.000000<N> ]"},
+	}
+
+	for _, tt := range tests {
+		env := newUpdateTestEnvironment(t)
+		env.Aliases = map[string]string{"#p": "profile"}
+
+		evaluated := testEvalUpdate(t, tt.input, env)
+		if isError(evaluated) {
+			t.Fatalf("unexpected error for %q: %s", tt.input, evaluated.Inspect())
+		}
+
+		val := testEval(t, tt.path, env)
+		if val.Inspect() != tt.expected {
+			t.Errorf("wrong value of %s for %q. got=%q, want=%q", tt.path, tt.input, val.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestEvalUpdateRemoveListElements(t *testing.T) {
+	tests := []struct {
+		input    string
+		path     string
+		expected string
+	}{
+		{"REMOVE letters[0], letters[1]", "letters", "[ c<S> ]"},
+		{"REMOVE letters[1], letters[0]", "letters", "[ c<S> ]"},
+		{"REMOVE #l[0], letters[1]", "letters", "[ c<S> ]"},
+		{"REMOVE letters[0], letters[2], letters[7]", "letters", "[ b<S> ]"},
+		{"SET letters[2] = :d REMOVE letters[0]", "letters", "[ b<S> d<S> ]"},
+		{"REMOVE matrix[0], matrix[1][0]", "matrix", "[ [ d<S> ]<L> ]"},
+		{"REMOVE matrix[0][0], matrix[0][1], matrix[1]", "matrix", "[ [ ]<L> ]"},
+	}
+
+	for _, tt := range tests {
+		env := NewEnvironment()
+		env.Aliases = map[string]string{"#l": "letters"}
+
+		err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+			"letters": {L: []*dynamodb.AttributeValue{{S: aws.String("a")}, {S: aws.String("b")}, {S: aws.String("c")}}},
+			"matrix": {L: []*dynamodb.AttributeValue{
+				{L: []*dynamodb.AttributeValue{{S: aws.String("a")}, {S: aws.String("b")}}},
+				{L: []*dynamodb.AttributeValue{{S: aws.String("c")}, {S: aws.String("d")}}},
+			}},
+			":d": {S: aws.String("d")},
+		})
+		if err != nil {
+			t.Fatalf("error adding attributes %#v", err)
+		}
+
+		evaluated := testEvalUpdate(t, tt.input, env)
+		if isError(evaluated) {
+			t.Fatalf("unexpected error for %q: %s", tt.input, evaluated.Inspect())
+		}
+
+		val, ok := env.Get(tt.path)
+		if !ok {
+			t.Fatalf("attribute %q not found after %q", tt.path, tt.input)
+		}
+
+		if val.Inspect() != tt.expected {
+			t.Errorf("wrong value for %q. got=%s, want=%s", tt.input, val.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestEvalUpdateNestedPathsErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"SET missing.name = :str", "the document path provided in the update expression is invalid for update: missing.name"},
+		{"SET total.name = :str", "the document path provided in the update expression is invalid for update: total.name"},
+		{"SET profile[0] = :str", "the document path provided in the update expression is invalid for update: profile[0]"},
+		{"ADD profile.age :num", "the ADD action only supports top level attributes: profile.age"},
+		{"SET profile = :str REMOVE profile.age", "two document paths overlap with each other: profile.age"},
+	}
+
+	for _, tt := range tests {
+		env := newUpdateTestEnvironment(t)
+		evaluated := testEvalUpdate(t, tt.input, env)
+
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("no error object returned for %s. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %s. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func newUpdateTestEnvironment(t *testing.T) *Environment {
+	env := NewEnvironment()
+
+	err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+		"colors":   {SS: []*string{aws.String("red"), aws.String("blue")}},
+		"scores":   {NS: []*string{aws.String("10"), aws.String("1")}},
+		"data":     {BS: [][]byte{[]byte("c"), []byte("a")}},
+		"total":    {N: aws.String("10")},
+		"profile":  {M: map[string]*dynamodb.AttributeValue{"age": {N: aws.String("30")}}},
+		"items":    {L: []*dynamodb.AttributeValue{{N: aws.String("10")}, {N: aws.String("20")}}},
+		":num":     {N: aws.String("1")},
+		":str":     {S: aws.String("txt")},
+		":zero":    {N: aws.String("0")},
+		":events":  {L: []*dynamodb.AttributeValue{{S: aws.String("login")}}},
+		":newTags": {SS: []*string{aws.String("a"), aws.String("b")}},
+		":colors":  {SS: []*string{aws.String("red"), aws.String("blue")}},
+		":newNumbers": {
+			NS: []*string{aws.String("1"), aws.String("2")},
+		},
+		":newBinaries": {
+			BS: [][]byte{[]byte("a"), []byte("b")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error adding attributes %#v", err)
+	}
+
+	return env
+}
+
+func testEvalUpdate(t *testing.T, input string, env *Environment) Object {
+	l := NewLexer(input)
+	p := NewParser(l)
+	update := p.ParseUpdateExpression()
+
+	if len(p.errors) != 0 {
+		t.Fatalf("parsing %q failed: %s", input, strings.Join(p.errors, ";\n"))
+	}
+
+	return EvalUpdate(update, env)
+}
+
+func TestEvalUpdateArithmetic(t *testing.T) {
+	tests := []struct {
+		input     string
+		attribute string
+		expected  string
+	}{
+		{"SET total = total + :num", "total", "11.000000"},
+		{"SET total = total - :num", "total", "9.000000"},
+		{"SET copy = :num - total", "copy", "-9.000000"},
+		{"SET copy = :num - -total", "copy", "11.000000"},
+		{"SET copy = total - :num - :num", "copy", "8.000000"},
+		{"SET total = profile.age + items[1]", "total", "50.000000"},
+	}
+
+	for _, tt := range tests {
+		env := newUpdateTestEnvironment(t)
+
+		evaluated := testEvalUpdate(t, tt.input, env)
+		if isError(evaluated) {
+			t.Fatalf("unexpected error for %q: %s", tt.input, evaluated.Inspect())
+		}
+
+		val, ok := env.Get(tt.attribute)
+		if !ok {
+			t.Fatalf("attribute %q not found after %q", tt.attribute, tt.input)
+		}
+
+		if val.Inspect() != tt.expected {
+			t.Errorf("wrong value for %q. got=%s, want=%s", tt.input, val.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestEvalUpdateFunctions(t *testing.T) {
+	tests := []struct {
+		input     string
+		attribute string
+		expected  string
+	}{
+		{"SET views = if_not_exists(views, :zero) + :num", "views", "1.000000"},
+		{"SET total = if_not_exists(total, :zero) + :num", "total", "11.000000"},
+		{"SET copy = if_not_exists(profile.age, :zero)", "copy", "30.000000"},
+		{"SET items = list_append(items, :events)", "items", "[ 10.000000<N> 20.000000<N> login<S> ]"},
+		{"SET items = list_append(:events, items)", "items", "[ login<S> 10.000000<N> 20.000000<N> ]"},
+		{"SET events = list_append(if_not_exists(events, :events), :events)", "events", "[ login<S> login<S> ]"},
+	}
+
+	for _, tt := range tests {
+		env := newUpdateTestEnvironment(t)
+
+		evaluated := testEvalUpdate(t, tt.input, env)
+		if isError(evaluated) {
+			t.Fatalf("unexpected error for %q: %s", tt.input, evaluated.Inspect())
+		}
+
+		val, ok := env.Get(tt.attribute)
+		if !ok {
+			t.Fatalf("attribute %q not found after %q", tt.attribute, tt.input)
+		}
+
+		if val.Inspect() != tt.expected {
+			t.Errorf("wrong value for %q. got=%s, want=%s", tt.input, val.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestEvalUpdateArithmeticErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{
+			"SET total = missing + :num",
+			"the provided expression refers to an attribute that does not exist in the item: missing",
+		},
+		{
+			"SET total = total + :str",
+			"an operand in the update expression has an incorrect data type: N + S",
+		},
+		{
+			"SET total = colors - :num",
+			"an operand in the update expression has an incorrect data type: SS - N",
+		},
+	}
+
+	for _, tt := range tests {
+		env := newUpdateTestEnvironment(t)
+		evaluated := testEvalUpdate(t, tt.input, env)
+
+		errObj, ok := evaluated.(*Error)
+		if !ok {
+			t.Errorf("no error object returned for %s. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %s. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestEvalUpdateNestingDepth(t *testing.T) {
+	keys := strings.Repeat(".k", maxNestingDepth)
+
+	tests := []struct {
+		input string
+		value *dynamodb.AttributeValue
+		// expectedError is empty when the update is valid
+		expectedError string
+	}{
+		{"SET doc = :v", nestedMapAttribute(32), ""},
+		{"SET doc = :v", nestedMapAttribute(33), "nesting levels have exceeded supported limits: doc"},
+		{"SET doc = :v", &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{nestedMapAttribute(32)}}, "nesting levels have exceeded supported limits: doc"},
+		{"SET deep" + keys + " = :v", &dynamodb.AttributeValue{N: aws.String("1")}, ""},
+		{"SET deep" + keys + " = :v", nestedMapAttribute(1), "nesting levels have exceeded supported limits: deep"},
+		{"SET deep" + keys + " = :v", &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{}}, "nesting levels have exceeded supported limits: deep"},
+		{"SET deep" + keys[2:] + " = :v", &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{}}, ""},
+	}
+
+	for _, tt := range tests {
+		env := newUpdateTestEnvironment(t)
+
+		err := env.AddAttributes(map[string]*dynamodb.AttributeValue{
+			"deep": nestedMapAttribute(32),
+			":v":   tt.value,
+		})
+		if err != nil {
+			t.Fatalf("error adding attributes %#v", err)
+		}
+
+		evaluated := testEvalUpdate(t, tt.input, env)
+
+		if tt.expectedError == "" {
+			if isError(evaluated) {
+				t.Errorf("unexpected error for %q: %s", tt.input, evaluated.Inspect())
+			}
+
+			continue
+		}
+
+		errObj, ok := evaluated.(*Error)
+		if !ok || errObj.Message != tt.expectedError {
+			t.Errorf("wrong result for %q. expected=%q, got=%s", tt.input, tt.expectedError, evaluated.Inspect())
+		}
+	}
+}
+
+// nestedMapAttribute returns maps nested to the given depth with a number in the innermost one
+func nestedMapAttribute(depth int) *dynamodb.AttributeValue {
+	val := &dynamodb.AttributeValue{N: aws.String("1")}
+
+	for i := 0; i < depth; i++ {
+		val = &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"k": val}}
+	}
+
+	return val
+}
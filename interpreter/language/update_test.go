@@ -0,0 +1,275 @@
+package language
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestMergeUpdates(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected string
+	}{
+		{
+			"SET a = :a",
+			"SET version = :v",
+			"SET a = :a, version = :v",
+		},
+		{
+			"REMOVE tmp ADD views :one",
+			"SET version = :v ADD counter :one",
+			"SET version = :v REMOVE tmp ADD views :one, counter :one",
+		},
+		{
+			"DELETE tags :t",
+			"SET a.b = :b",
+			"SET a.b = :b DELETE tags :t",
+		},
+		{
+			"SET list[0] = :a",
+			"SET list[1] = :b, lists = :c",
+			"SET list[0] = :a, list[1] = :b, lists = :c",
+		},
+	}
+
+	for _, tt := range tests {
+		merged, err := MergeUpdates(testParseUpdate(t, tt.a), testParseUpdate(t, tt.b))
+		if err != nil {
+			t.Fatalf("unexpected error merging %q and %q: %v", tt.a, tt.b, err)
+		}
+
+		if merged.String() != tt.expected {
+			t.Errorf("wrong merge of %q and %q. expected=%q, got=%q", tt.a, tt.b, tt.expected, merged.String())
+		}
+	}
+}
+
+func TestMergeUpdatesConflicts(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected string
+	}{
+		{
+			"SET version = :v",
+			"SET version = :other",
+			"two document paths overlap with each other: version and version",
+		},
+		{
+			"SET a = :a",
+			"REMOVE a.b",
+			"two document paths overlap with each other: a and a.b",
+		},
+		{
+			"ADD counter :one REMOVE list[0].b",
+			"DELETE list :v",
+			"two document paths overlap with each other: list[0].b and list",
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := MergeUpdates(testParseUpdate(t, tt.a), testParseUpdate(t, tt.b))
+		if !errors.Is(err, ErrOverlappingPaths) {
+			t.Fatalf("expected overlapping paths error merging %q and %q. got=%v", tt.a, tt.b, err)
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, err.Error())
+		}
+	}
+}
+
+func TestValidateUpdateNotKeys(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"SET pk = :v", "one or more parameter values were invalid: Cannot update attribute pk. This attribute is part of the key"},
+		{"REMOVE sk", "one or more parameter values were invalid: Cannot update attribute sk. This attribute is part of the key"},
+		{"SET a = :a ADD pk :n", "one or more parameter values were invalid: Cannot update attribute pk. This attribute is part of the key"},
+		{"SET sk.nested = :v", "one or more parameter values were invalid: Cannot update attribute sk. This attribute is part of the key"},
+		{"SET a = pk, b = sk + :n REMOVE pks DELETE tags :t", ""},
+		{"SET data.pk = :v, list[0] = :v", ""},
+		{"SET #k = :v", "one or more parameter values were invalid: Cannot update attribute pk. This attribute is part of the key"},
+		{"REMOVE #s", "one or more parameter values were invalid: Cannot update attribute sk. This attribute is part of the key"},
+		{"SET #d = :v", ""},
+	}
+
+	names := map[string]*string{
+		"#k": aws.String("pk"),
+		"#s": aws.String("sk"),
+		"#d": aws.String("data"),
+	}
+
+	for _, tt := range tests {
+		err := ValidateUpdateNotKeys(testParseUpdate(t, tt.input), names, "pk", "sk")
+
+		if tt.expectedMessage == "" {
+			if err != nil {
+				t.Errorf("unexpected error for %q: %v", tt.input, err)
+			}
+
+			continue
+		}
+
+		if !errors.Is(err, ErrKeyUpdate) {
+			t.Fatalf("expected a key update error for %q. got=%v", tt.input, err)
+		}
+
+		if err.Error() != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, err.Error())
+		}
+	}
+
+	if err := ValidateUpdateNotKeys(testParseUpdate(t, "SET sk = :v"), nil, "pk", ""); err != nil {
+		t.Errorf("unexpected error for a table without sort key: %v", err)
+	}
+}
+
+func TestExplainUpdate(t *testing.T) {
+	update := testParseUpdate(t, "REMOVE temp, a.b[0] SET views = :one, #n = :name ADD counter :n DELETE tags :old")
+
+	expected := `set attribute 'views' to ':one'
+set attribute '#n' to ':name'
+remove attribute 'temp'
+remove attribute 'a.b[0]'
+add ':n' to attribute 'counter'
+delete the elements of ':old' from attribute 'tags'`
+
+	actual := ExplainUpdate(update)
+	if actual != expected {
+		t.Errorf("wrong explanation. expected=%q, got=%q", expected, actual)
+	}
+
+	if ExplainUpdate(nil) != "" {
+		t.Errorf("expected an empty explanation for a nil update, got=%q", ExplainUpdate(nil))
+	}
+}
+
+func TestIsNoOp(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"id":      {S: aws.String("1")},
+		"count":   {N: aws.String("5")},
+		"tags":    {SS: []*string{aws.String("a"), aws.String("b")}},
+		"profile": {M: map[string]*dynamodb.AttributeValue{"age": {N: aws.String("30")}}},
+	}
+
+	values := map[string]*dynamodb.AttributeValue{
+		":zero": {N: aws.String("0")},
+		":one":  {N: aws.String("1")},
+		":five": {N: aws.String("5.0")},
+		":a":    {SS: []*string{aws.String("a")}},
+		":c":    {SS: []*string{aws.String("c")}},
+	}
+
+	names := map[string]*string{"#c": aws.String("count")}
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"SET count = count", true},
+		{"SET #c = #c, profile.age = profile.age", true},
+		{"ADD count :zero", true},
+		{"SET count = count + :zero", true},
+		{"SET count = :five", true},
+		{"ADD tags :a", true},
+		{"DELETE tags :c", true},
+		{"REMOVE missing", true},
+		{"SET count = if_not_exists(count, :zero)", true},
+		{"ADD count :one", false},
+		{"SET #c = :one", false},
+		{"SET other = :one", false},
+		{"REMOVE profile.age", false},
+		{"ADD tags :c", false},
+		{"SET count = missing", false},
+	}
+
+	for _, tt := range tests {
+		if actual := IsNoOp(testParseUpdate(t, tt.input), item, names, values); actual != tt.expected {
+			t.Errorf("wrong result for %q. expected=%v, got=%v", tt.input, tt.expected, actual)
+		}
+	}
+
+	if IsNoOp(nil, item, names, values) {
+		t.Errorf("expected a nil update not to be a no-op")
+	}
+}
+
+func TestToSDKUpdate(t *testing.T) {
+	update := testParseUpdate(t, "REMOVE temp SET views = :one, #n = :name ADD counter :n DELETE tags :old")
+
+	names := map[string]*string{"#n": aws.String("name")}
+	values := map[string]*dynamodb.AttributeValue{
+		":one":  {N: aws.String("1")},
+		":name": {S: aws.String("Alice")},
+		":n":    {N: aws.String("5")},
+		":old":  {SS: aws.StringSlice([]string{"x"})},
+	}
+
+	expected := map[string]*dynamodb.AttributeValueUpdate{
+		"views":   {Action: aws.String("PUT"), Value: values[":one"]},
+		"name":    {Action: aws.String("PUT"), Value: values[":name"]},
+		"temp":    {Action: aws.String("DELETE")},
+		"counter": {Action: aws.String("ADD"), Value: values[":n"]},
+		"tags":    {Action: aws.String("DELETE"), Value: values[":old"]},
+	}
+
+	actual, err := ToSDKUpdate(update, names, values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("wrong updates. expected=%+v, got=%+v", expected, actual)
+	}
+
+	actual, err = ToSDKUpdate(nil, nil, nil)
+	if err != nil || len(actual) != 0 {
+		t.Errorf("expected no updates for a nil update, got=%+v, %v", actual, err)
+	}
+}
+
+func TestToSDKUpdateUnsupported(t *testing.T) {
+	values := map[string]*dynamodb.AttributeValue{
+		":one": {N: aws.String("1")},
+	}
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"SET views = views + :one", "the update action can not be represented as an AttributeValueUpdate: value (views + :one) is not an expression attribute value"},
+		{"SET views = if_not_exists(views, :one)", "the update action can not be represented as an AttributeValueUpdate: value if_not_exists(views, :one) is not an expression attribute value"},
+		{"SET views = total", "the update action can not be represented as an AttributeValueUpdate: value total is not an expression attribute value"},
+		{"SET stats.views = :one", "the update action can not be represented as an AttributeValueUpdate: nested path stats.views"},
+		{"REMOVE list[0]", "the update action can not be represented as an AttributeValueUpdate: nested path list[0]"},
+		{"SET #v = :one", "the update action can not be represented as an AttributeValueUpdate: undefined attribute name #v"},
+		{"ADD views :two", "the update action can not be represented as an AttributeValueUpdate: undefined attribute value :two"},
+	}
+
+	for _, tt := range tests {
+		_, err := ToSDKUpdate(testParseUpdate(t, tt.input), nil, values)
+		if !errors.Is(err, ErrUnsupportedSDKUpdate) {
+			t.Fatalf("expected an unsupported update error for %q. got=%v", tt.input, err)
+		}
+
+		if err.Error() != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, err.Error())
+		}
+	}
+}
+
+func testParseUpdate(t *testing.T, input string) *UpdateExpression {
+	l := NewLexer(input)
+	p := NewParser(l)
+	update := p.ParseUpdateExpression()
+	checkParserErrors(t, p)
+
+	return update
+}
@@ -0,0 +1,156 @@
+package language
+
+// Walk traverses the AST in pre-order calling fn for each node, the children
+// of a node are skipped when fn returns false
+func Walk(n Node, fn func(Node) bool) {
+	if isNilNode(n) || !fn(n) {
+		return
+	}
+
+	for _, child := range children(n) {
+		Walk(child, fn)
+	}
+}
+
+// CollectIdentifiers returns the attribute names and placeholders used by the expression in
+// order of appearance without duplicates, the function names are not included
+func CollectIdentifiers(expr *DynamoExpression) []string {
+	identifiers := []string{}
+	seen := map[string]bool{}
+
+	var visit func(Node) bool
+
+	visit = func(n Node) bool {
+		switch node := n.(type) {
+		case *CallExpression:
+			for _, arg := range node.Arguments {
+				Walk(arg, visit)
+			}
+
+			return false
+		case *Identifier:
+			if !seen[node.Value] {
+				seen[node.Value] = true
+				identifiers = append(identifiers, node.Value)
+			}
+		}
+
+		return true
+	}
+
+	Walk(expr, visit)
+
+	return identifiers
+}
+
+// OperatorsUsed counts the operators, functions and update actions used by the expression, the
+// functions are counted by their name, e.g. size, and the update actions by their clause, e.g. SET
+func OperatorsUsed(expr Node) map[TokenType]int {
+	used := map[TokenType]int{}
+
+	Walk(expr, func(n Node) bool {
+		switch node := n.(type) {
+		case *PrefixExpression:
+			used[TokenType(node.Operator)]++
+		case *InfixExpression:
+			used[TokenType(node.Operator)]++
+		case *BetweenExpression:
+			used[BETWEEN]++
+		case *InExpression:
+			used[IN]++
+		case *UpdateAction:
+			used[node.Token.Type]++
+		case *CallExpression:
+			if node.Function != nil {
+				used[TokenType(node.Function.String())]++
+			}
+		}
+
+		return true
+	})
+
+	return used
+}
+
+func children(n Node) []Node {
+	switch node := n.(type) {
+	case *DynamoExpression:
+		return []Node{node.Statement}
+	case *ExpressionStatement:
+		return []Node{node.Expression}
+	case *PrefixExpression:
+		return []Node{node.Right}
+	case *InfixExpression:
+		return []Node{node.Left, node.Right}
+	case *BetweenExpression:
+		return []Node{node.Left, node.Range[0], node.Range[1]}
+	case *InExpression:
+		nodes := []Node{node.Left}
+		for _, c := range node.Candidates {
+			nodes = append(nodes, c)
+		}
+
+		return nodes
+	case *CallExpression:
+		nodes := []Node{node.Function}
+		for _, arg := range node.Arguments {
+			nodes = append(nodes, arg)
+		}
+
+		return nodes
+	case *sharedExpression:
+		return []Node{node.Expression}
+	case *attributeAccessor:
+		return []Node{node.Identifier}
+	case *IndexExpression:
+		if node.Key != nil {
+			return []Node{node.Left, node.Key}
+		}
+
+		return []Node{node.Left}
+	case *UpdateExpression:
+		return []Node{node.Statement}
+	case *UpdateStatement:
+		nodes := []Node{}
+		for _, c := range node.Clauses() {
+			nodes = append(nodes, c)
+		}
+
+		return nodes
+	case *UpdateClause:
+		nodes := []Node{}
+		for _, a := range node.Actions {
+			nodes = append(nodes, a)
+		}
+
+		return nodes
+	case *UpdateAction:
+		return []Node{node.Path, node.Value}
+	case *ProjectionExpression:
+		nodes := []Node{}
+		for _, p := range node.Paths {
+			nodes = append(nodes, p)
+		}
+
+		return nodes
+	}
+
+	return nil
+}
+
+func isNilNode(n Node) bool {
+	switch node := n.(type) {
+	case nil:
+		return true
+	case *DynamoExpression:
+		return node == nil
+	case *UpdateExpression:
+		return node == nil
+	case *ExpressionStatement:
+		return node == nil
+	case *UpdateStatement:
+		return node == nil
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package language
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	program := testParse(t, "a BETWEEN :x AND :y AND contains(#t.b[0], :v) OR NOT c IN (:x, d)")
+
+	visited := []string{}
+
+	Walk(program, func(n Node) bool {
+		if identifier, ok := n.(*Identifier); ok {
+			visited = append(visited, identifier.Value)
+		}
+
+		return true
+	})
+
+	expected := []string{"a", ":x", ":y", "contains", "#t", "b", ":v", "c", ":x", "d"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("wrong visited identifiers. expected=%v, got=%v", expected, visited)
+	}
+
+	visited = []string{}
+
+	Walk(program, func(n Node) bool {
+		if identifier, ok := n.(*Identifier); ok {
+			visited = append(visited, identifier.Value)
+		}
+
+		// the operands of the functions and NOT are skipped
+		_, isCall := n.(*CallExpression)
+		_, isPrefix := n.(*PrefixExpression)
+
+		return !isCall && !isPrefix
+	})
+
+	expected = []string{"a", ":x", ":y"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("wrong visited identifiers skipping the children. expected=%v, got=%v", expected, visited)
+	}
+
+	Walk((*DynamoExpression)(nil), func(n Node) bool {
+		t.Errorf("unexpected node visited for a nil expression: %v", n)
+
+		return true
+	})
+}
+
+func TestCollectIdentifiers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"a = :a", []string{"a", ":a"}},
+		{"#n = :a AND #n <> :b", []string{"#n", ":a", ":b"}},
+		{"size(#list[1].#k) > :min OR attribute_not_exists(b)", []string{"#list", "#k", ":min", "b"}},
+		{"a BETWEEN :lo AND :hi", []string{"a", ":lo", ":hi"}},
+	}
+
+	for _, tt := range tests {
+		actual := CollectIdentifiers(testParse(t, tt.input))
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("wrong identifiers for %q. expected=%v, got=%v", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestOperatorsUsed(t *testing.T) {
+	tests := []struct {
+		node     Node
+		expected map[TokenType]int
+	}{
+		{
+			testParse(t, "a = :a AND (b <> :b OR NOT c IN (:x, :y)) AND d BETWEEN :lo AND :hi"),
+			map[TokenType]int{AND: 2, OR: 1, NOT: 1, EQ: 1, NotEQ: 1, IN: 1, BETWEEN: 1},
+		},
+		{
+			testParse(t, "size(a) > :n AND size(b[0]) <= :n OR begins_with(c, :p) AND contains(c, :p)"),
+			map[TokenType]int{AND: 2, OR: 1, GT: 1, LTE: 1, "size": 2, "begins_with": 1, "contains": 1},
+		},
+		{
+			testParseUpdate(t, "SET a = if_not_exists(a, :z) + :n, b = :x - -:y REMOVE c ADD d :n"),
+			map[TokenType]int{SET: 2, REMOVE: 1, ADD: 1, PLUS: 1, MINUS: 2, "if_not_exists": 1},
+		},
+		{
+			testParse(t, "a"),
+			map[TokenType]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		actual := OperatorsUsed(tt.node)
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("wrong operators for %q. expected=%v, got=%v", tt.node.String(), tt.expected, actual)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 )
 
 type matchTestCase struct {
@@ -78,7 +79,7 @@ func TestLanguageMatch(t *testing.T) {
 			name: "type mismatch",
 			input: MatchInput{
 				TableName:  "test",
-				Expression: "contains(txt, :b)",
+				Expression: "begins_with(txt, :b)",
 				Item:       item,
 				Attributes: map[string]*dynamodb.AttributeValue{
 					":b": {
@@ -88,6 +89,20 @@ func TestLanguageMatch(t *testing.T) {
 			},
 			expectedErr: ErrSyntaxError,
 		},
+		{
+			name: "contains type mismatch",
+			input: MatchInput{
+				TableName:  "test",
+				Expression: "contains(txt, :b)",
+				Item:       item,
+				Attributes: map[string]*dynamodb.AttributeValue{
+					":b": {
+						BOOL: aws.Bool(true),
+					},
+				},
+			},
+			output: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -97,11 +112,416 @@ func TestLanguageMatch(t *testing.T) {
 	}
 }
 
+func TestLanguageMatchAttributeNotExists(t *testing.T) {
+	testCases := []matchTestCase{
+		{
+			name: "nil item",
+			input: MatchInput{
+				TableName:  "test",
+				Expression: "attribute_not_exists(pk)",
+				Item:       nil,
+			},
+			output: true,
+		},
+		{
+			name: "empty item",
+			input: MatchInput{
+				TableName:  "test",
+				Expression: "attribute_not_exists(pk)",
+				Item:       map[string]*dynamodb.AttributeValue{},
+				Attributes: map[string]*dynamodb.AttributeValue{},
+			},
+			output: true,
+		},
+		{
+			name: "item with the attribute",
+			input: MatchInput{
+				TableName:  "test",
+				Expression: "attribute_not_exists(pk)",
+				Item: map[string]*dynamodb.AttributeValue{
+					"pk": {S: aws.String("001")},
+				},
+			},
+			output: false,
+		},
+		{
+			name: "item with a NULL attribute",
+			input: MatchInput{
+				TableName:  "test",
+				Expression: "attribute_not_exists(pk)",
+				Item: map[string]*dynamodb.AttributeValue{
+					"pk": {NULL: aws.Bool(true)},
+				},
+			},
+			output: false,
+		},
+		{
+			name: "name placeholder",
+			input: MatchInput{
+				TableName:  "test",
+				Expression: "attribute_not_exists(#pk)",
+				Item: map[string]*dynamodb.AttributeValue{
+					"pk": {S: aws.String("001")},
+				},
+				Aliases: map[string]*string{"#pk": aws.String("pk")},
+			},
+			output: false,
+		},
+		{
+			name: "nil attribute value",
+			input: MatchInput{
+				TableName:  "test",
+				Expression: "attribute_not_exists(pk)",
+				Item: map[string]*dynamodb.AttributeValue{
+					"pk": nil,
+				},
+			},
+			expectedErr: ErrUnsupportedFeature,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matchTestCaseVerify(tc, t)
+		})
+	}
+}
+
+func TestLanguageMatchBinary(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"data": {B: []byte{0xde, 0xad, 0xbe, 0xef}},
+	}
+
+	testCases := []struct {
+		name        string
+		expression  string
+		placeholder []byte
+		output      bool
+	}{
+		{"equal", "data = :b", []byte{0xde, 0xad, 0xbe, 0xef}, true},
+		{"different", "data = :b", []byte{0xde, 0xad, 0xbe, 0xee}, false},
+		{"shorter placeholder", "data = :b", []byte{0xde, 0xad, 0xbe}, false},
+		{"longer placeholder", "data = :b", []byte{0xde, 0xad, 0xbe, 0xef, 0x00}, false},
+		{"not equal", "data <> :b", []byte{0xde, 0xad}, true},
+		{"byte ordering", "data < :b", []byte{0xdf}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matchTestCaseVerify(matchTestCase{
+				input: MatchInput{
+					TableName:  "test",
+					Expression: tc.expression,
+					Item:       item,
+					Attributes: map[string]*dynamodb.AttributeValue{
+						":b": {B: tc.placeholder},
+					},
+				},
+				output: tc.output,
+			}, t)
+		})
+	}
+}
+
+func TestLanguageMatchPlaceholders(t *testing.T) {
+	attributes := map[string]*dynamodb.AttributeValue{
+		":one":   {N: aws.String("1")},
+		":two":   {N: aws.String("2")},
+		":apple": {S: aws.String("apple")},
+		":berry": {S: aws.String("berry")},
+	}
+
+	items := map[string]map[string]*dynamodb.AttributeValue{
+		"nil item":   nil,
+		"empty item": {},
+		"item":       {"id": {S: aws.String("001")}, ":one": {N: aws.String("5")}},
+	}
+
+	testCases := []struct {
+		expression string
+		output     bool
+	}{
+		{":one < :two", true},
+		{":two < :one", false},
+		{":apple < :berry", true},
+		{":berry <= :apple", false},
+		{":one = :one", true},
+	}
+
+	for name, item := range items {
+		for _, tc := range testCases {
+			t.Run(name+" "+tc.expression, func(t *testing.T) {
+				matchTestCaseVerify(matchTestCase{
+					input: MatchInput{
+						TableName:  "test",
+						Expression: tc.expression,
+						Item:       item,
+						Attributes: attributes,
+					},
+					output: tc.output,
+				}, t)
+			})
+		}
+	}
+}
+
+func TestLanguageMatchNilMaps(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"name": {S: aws.String("Juan")},
+	}
+
+	testCases := []struct {
+		expression string
+		output     bool
+		message    string
+	}{
+		{expression: "attribute_exists(name)", output: true},
+		{expression: "attribute_not_exists(age) AND name = other", output: false},
+		{
+			expression: "name = :name",
+			message:    "syntax error: an expression attribute value used in expression is not defined; attribute value: :name",
+		},
+		{
+			expression: "attribute_exists(#n)",
+			message:    "syntax error: an expression attribute name used in the document path is not defined; attribute name: #n",
+		},
+	}
+
+	lang := Language{}
+
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(t *testing.T) {
+			for _, empty := range []bool{false, true} {
+				input := MatchInput{TableName: "test", Expression: tc.expression, Item: item}
+				if empty {
+					input.Aliases = map[string]*string{}
+					input.Attributes = map[string]*dynamodb.AttributeValue{}
+				}
+
+				output, err := lang.Match(input)
+
+				if tc.message == "" {
+					if err != nil || output != tc.output {
+						t.Errorf("unexpected result with empty=%t. expected=%t, got=%t, %v", empty, tc.output, output, err)
+					}
+
+					continue
+				}
+
+				if !errors.Is(err, ErrSyntaxError) || err.Error() != tc.message {
+					t.Errorf("wrong error with empty=%t. expected=%q, got=%v", empty, tc.message, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLanguageMatchNestedAliases(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"profile": {M: map[string]*dynamodb.AttributeValue{
+			"name": {S: aws.String("Juan")},
+			"address": {M: map[string]*dynamodb.AttributeValue{
+				"city": {S: aws.String("Bogota")},
+			}},
+		}},
+	}
+
+	aliases := map[string]*string{
+		"#p": aws.String("profile"),
+		"#n": aws.String("name"),
+		"#a": aws.String("address"),
+		"#c": aws.String("city"),
+	}
+
+	testCases := []struct {
+		expression string
+		output     bool
+	}{
+		{"profile.#n = :name", true},
+		{"#p.name = :name", true},
+		{"#p.#n = :name", true},
+		{"profile.#a.city = :city", true},
+		{"profile.address.#c = :city", true},
+		{"#p.#a.#c = :city", true},
+		{"profile.#c = :name", false},
+		{"#p.#n = :city", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(t *testing.T) {
+			matchTestCaseVerify(matchTestCase{
+				input: MatchInput{
+					TableName:  "test",
+					Expression: tc.expression,
+					Item:       item,
+					Aliases:    aliases,
+					Attributes: map[string]*dynamodb.AttributeValue{
+						":name": {S: aws.String("Juan")},
+						":city": {S: aws.String("Bogota")},
+					},
+				},
+				output: tc.output,
+			}, t)
+		})
+	}
+}
+
+// sdkMatchInput builds the input with the expression generated by the AWS SDK builder, which
+// replaces the names and values by numeric placeholders, e.g. (#0 = :0) AND (begins_with (#1, :1))
+func sdkMatchInput(t *testing.T, cond expression.ConditionBuilder, item map[string]*dynamodb.AttributeValue) MatchInput {
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		t.Fatalf("error building the expression %#v", err)
+	}
+
+	return MatchInput{
+		TableName:  "test",
+		Expression: aws.StringValue(expr.Condition()),
+		Item:       item,
+		Attributes: expr.Values(),
+		Aliases:    expr.Names(),
+	}
+}
+
+func TestLanguageMatchSDKExpressions(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"id":      {S: aws.String("001")},
+		"name":    {S: aws.String("john doe")},
+		"age":     {N: aws.String("30")},
+		"tags":    {SS: []*string{aws.String("a"), aws.String("b")}},
+		"profile": {M: map[string]*dynamodb.AttributeValue{"city": {S: aws.String("bogota")}}},
+	}
+
+	testCases := []struct {
+		name   string
+		cond   expression.ConditionBuilder
+		output bool
+	}{
+		{
+			"equal and begins_with",
+			expression.Name("id").Equal(expression.Value("001")).And(expression.Name("name").BeginsWith("john")),
+			true,
+		},
+		{
+			"between",
+			expression.Name("age").Between(expression.Value(18), expression.Value(25)),
+			false,
+		},
+		{
+			"in",
+			expression.Name("age").In(expression.Value(20), expression.Value(30)),
+			true,
+		},
+		{
+			"nested path and size",
+			expression.Name("profile.city").Equal(expression.Value("bogota")).And(expression.Name("tags").Size().GreaterThan(expression.Value(1))),
+			true,
+		},
+		{
+			"functions",
+			expression.Or(
+				expression.AttributeNotExists(expression.Name("deleted")).And(expression.Contains(expression.Name("tags"), "c")),
+				expression.Not(expression.Name("age").LessThanEqual(expression.Value(29))),
+			),
+			true,
+		},
+		{
+			"attribute type",
+			expression.AttributeType(expression.Name("tags"), expression.StringSet).And(expression.Name("name").NotEqual(expression.Value("john doe"))),
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matchTestCaseVerify(matchTestCase{
+				input:  sdkMatchInput(t, tc.cond, item),
+				output: tc.output,
+			}, t)
+		})
+	}
+
+	matchTestCaseVerify(matchTestCase{
+		input: MatchInput{
+			TableName:  "test",
+			Expression: "#0 = :0 AND begins_with(#1, :1)",
+			Item:       item,
+			Attributes: map[string]*dynamodb.AttributeValue{
+				":0": {S: aws.String("001")},
+				":1": {S: aws.String("john")},
+			},
+			Aliases: map[string]*string{
+				"#0": aws.String("id"),
+				"#1": aws.String("name"),
+			},
+		},
+		output: true,
+	}, t)
+}
+
+func TestLanguageUpdateSDKExpression(t *testing.T) {
+	update := expression.Set(expression.Name("profile.city"), expression.Value("cali")).
+		Remove(expression.Name("old")).
+		Add(expression.Name("tags"), expression.Value(&dynamodb.AttributeValue{SS: []*string{aws.String("c")}}))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+	if err != nil {
+		t.Fatalf("error building the expression %#v", err)
+	}
+
+	item := map[string]*dynamodb.AttributeValue{
+		"old":     {BOOL: aws.Bool(true)},
+		"tags":    {SS: []*string{aws.String("a")}},
+		"profile": {M: map[string]*dynamodb.AttributeValue{"city": {S: aws.String("bogota")}}},
+	}
+
+	interpeter := Language{}
+
+	err = interpeter.Update(UpdateInput{
+		TableName:  "test",
+		Expression: aws.StringValue(expr.Update()),
+		Item:       item,
+		Attributes: expr.Values(),
+		Aliases:    expr.Names(),
+	})
+	if err != nil {
+		t.Fatalf("update %q failed with unexpected error: %v", aws.StringValue(expr.Update()), err)
+	}
+
+	if _, ok := item["old"]; ok || len(item["tags"].SS) != 2 || aws.StringValue(item["profile"].M["city"].S) != "cali" {
+		t.Errorf("the item was not updated by %q; got=%v", aws.StringValue(expr.Update()), item)
+	}
+}
+
 func TestLanguageUpdate(t *testing.T) {
 	interpeter := Language{}
 
 	err := interpeter.Update(UpdateInput{})
-	if !errors.Is(err, ErrUnsupportedFeature) {
-		t.Errorf("update failed with unexpected error; expected=%v, got=%v", ErrUnsupportedFeature, err)
+	if !errors.Is(err, ErrSyntaxError) {
+		t.Errorf("update failed with unexpected error; expected=%v, got=%v", ErrSyntaxError, err)
+	}
+
+	item := map[string]*dynamodb.AttributeValue{
+		"id":   {S: aws.String("001")},
+		"name": {S: aws.String("Juan")},
+		"old":  {BOOL: aws.Bool(true)},
+	}
+
+	err = interpeter.Update(UpdateInput{
+		Expression: "SET #n = :name REMOVE old",
+		Item:       item,
+		Attributes: map[string]*dynamodb.AttributeValue{
+			":name": {S: aws.String("Pedro")},
+		},
+		Aliases: map[string]*string{
+			"#n": aws.String("name"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("update failed with unexpected error: %v", err)
+	}
+
+	if len(item) != 2 || aws.StringValue(item["name"].S) != "Pedro" || aws.StringValue(item["id"].S) != "001" {
+		t.Errorf("the item was not updated in place; got=%v", item)
 	}
 }
@@ -0,0 +1,65 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/truora/minidyn/interpreter/language"
+)
+
+// LintQuery returns advisory notes about the filter and projection expressions of a query,
+// a note never means the query is invalid. DynamoDB applies the filter before the projection,
+// so the filter can use attributes that are not projected but they are not returned
+func LintQuery(filter, projection string, names map[string]*string) ([]string, error) {
+	if strings.TrimSpace(filter) == "" || strings.TrimSpace(projection) == "" {
+		return nil, nil
+	}
+
+	aliases := expressionAliases(names)
+
+	program, err := parseCondition(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := parseProjection(projection)
+	if err != nil {
+		return nil, err
+	}
+
+	var filterAttrs []string
+	if stmt, ok := program.Statement.(*language.ExpressionStatement); ok && stmt.Expression != nil {
+		filterAttrs = expressionAttributes(stmt.Expression, aliases)
+	}
+
+	projected := map[string]bool{}
+
+	for _, path := range paths.Paths {
+		for _, attr := range expressionAttributes(path, aliases) {
+			projected[attr] = true
+		}
+	}
+
+	notes := []string{}
+
+	for _, attr := range filterAttrs {
+		if !projected[attr] {
+			notes = append(notes, fmt.Sprintf("%s is used by the %s but it is not in the %s, the filter is applied before the projection so the attribute is not returned", attr, FilterExpression, ProjectionExpression))
+		}
+	}
+
+	return notes, nil
+}
+
+// expressionAttributes returns the top level attributes used by the expression with the aliases resolved
+func expressionAttributes(exp language.Expression, aliases map[string]string) []string {
+	attrs := language.ReferencedAttributes(exp)
+
+	for i, attr := range attrs {
+		if name, ok := aliases[attr]; ok {
+			attrs[i] = name
+		}
+	}
+
+	return attrs
+}
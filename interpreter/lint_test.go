@@ -0,0 +1,78 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestLintQuery(t *testing.T) {
+	names := map[string]*string{
+		"#s": aws.String("status"),
+		"#n": aws.String("name"),
+	}
+
+	testCases := []struct {
+		name       string
+		filter     string
+		projection string
+		notes      []string
+	}{
+		{
+			name:       "projected filter attributes",
+			filter:     "#s = :active AND size(tags) > :n",
+			projection: "id, status, tags[0]",
+			notes:      []string{},
+		},
+		{
+			name:       "filter attribute not projected",
+			filter:     "#s = :active AND begins_with(#n, :prefix)",
+			projection: "id, #n",
+			notes: []string{
+				"status is used by the FilterExpression but it is not in the ProjectionExpression, the filter is applied before the projection so the attribute is not returned",
+			},
+		},
+		{
+			name:       "repeated attribute",
+			filter:     "price > :lo AND price < :hi",
+			projection: "id",
+			notes: []string{
+				"price is used by the FilterExpression but it is not in the ProjectionExpression, the filter is applied before the projection so the attribute is not returned",
+			},
+		},
+		{
+			name:       "nested projection",
+			filter:     "profile.age > :age",
+			projection: "profile.#n",
+			notes:      []string{},
+		},
+		{
+			name:   "without projection",
+			filter: "#s = :active",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			notes, err := LintQuery(tc.filter, tc.projection, names)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(notes, tc.notes) {
+				t.Errorf("wrong notes. expected=%q, got=%q", tc.notes, notes)
+			}
+		})
+	}
+}
+
+func TestLintQuerySyntaxError(t *testing.T) {
+	for _, tc := range [][2]string{{"a = ", "a"}, {"a = :a", "a, :v"}, {"a = :a", "a, size(b)"}} {
+		_, err := LintQuery(tc[0], tc[1], nil)
+		if !errors.Is(err, ErrSyntaxError) {
+			t.Errorf("expected a syntax error for %q and %q, got=%v", tc[0], tc[1], err)
+		}
+	}
+}
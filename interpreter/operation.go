@@ -0,0 +1,178 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/truora/minidyn/interpreter/language"
+)
+
+// Names of the expressions used by the DynamoDB API operations
+const (
+	ConditionExpression    = "ConditionExpression"
+	FilterExpression       = "FilterExpression"
+	KeyConditionExpression = "KeyConditionExpression"
+	ProjectionExpression   = "ProjectionExpression"
+	UpdateExpression       = "UpdateExpression"
+)
+
+// operationExpressions the expressions accepted by each operation, in validation order
+var operationExpressions = map[string][]string{
+	"GetItem":    {ProjectionExpression},
+	"PutItem":    {ConditionExpression},
+	"DeleteItem": {ConditionExpression},
+	"UpdateItem": {UpdateExpression, ConditionExpression},
+	"Query":      {KeyConditionExpression, FilterExpression, ProjectionExpression},
+	"Scan":       {FilterExpression, ProjectionExpression},
+}
+
+// keyConditionFunctions the functions allowed in a key condition expression
+var keyConditionFunctions = map[string]bool{
+	"begins_with": true,
+}
+
+// ValidateForOperation parses the expressions and validates them against the rules of the
+// operation, e.g. Query, it returns the first error found. The unsupported expressions are
+// reported in the alphabetical order of their names
+func ValidateForOperation(op string, exprs map[string]string) error {
+	accepted, ok := operationExpressions[op]
+	if !ok {
+		return fmt.Errorf("%w: operation %s is not supported", ErrUnsupportedFeature, op)
+	}
+
+	names := make([]string, 0, len(exprs))
+	for name := range exprs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !contains(accepted, name) {
+			return fmt.Errorf("%w: %s is not supported by %s", ErrSyntaxError, name, op)
+		}
+	}
+
+	if _, ok := exprs[KeyConditionExpression]; op == "Query" && !ok {
+		return fmt.Errorf("%w: %s is required by %s", ErrSyntaxError, KeyConditionExpression, op)
+	}
+
+	for _, name := range accepted {
+		src, ok := exprs[name]
+		if !ok {
+			continue
+		}
+
+		err := validateExpression(name, src)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateExpression(name, src string) error {
+	switch name {
+	case UpdateExpression:
+		_, err := CompileUpdate(src)
+
+		return err
+	case ProjectionExpression:
+		_, err := parseProjection(src)
+
+		return err
+	}
+
+	program, err := parseCondition(src)
+	if err != nil {
+		return err
+	}
+
+	if name == KeyConditionExpression {
+		return validateKeyCondition(program)
+	}
+
+	return nil
+}
+
+func parseCondition(src string) (*language.DynamoExpression, error) {
+	l := language.NewLexer(src)
+	p := language.NewParser(l)
+	program := p.ParseDynamoExpression()
+
+	if err := parserError(p); err != nil {
+		return nil, err
+	}
+
+	return program, nil
+}
+
+// validateKeyCondition a key condition is one or two terms joined by AND, using the
+// comparators, BETWEEN or begins_with
+func validateKeyCondition(program *language.DynamoExpression) error {
+	stmt, ok := program.Statement.(*language.ExpressionStatement)
+	if !ok || stmt.Expression == nil {
+		return fmt.Errorf("%w: invalid %s", ErrSyntaxError, KeyConditionExpression)
+	}
+
+	terms := language.Conjuncts(stmt.Expression)
+	if len(terms) > 2 {
+		return fmt.Errorf("%w: %s must have at most two conditions", ErrSyntaxError, KeyConditionExpression)
+	}
+
+	for _, term := range terms {
+		switch node := term.(type) {
+		case *language.InfixExpression:
+			switch node.Operator {
+			case language.EQ, language.LT, language.LTE, language.GT, language.GTE:
+				continue
+			}
+
+			return invalidKeyConditionOperator(node.Operator)
+		case *language.BetweenExpression:
+			continue
+		case *language.CallExpression:
+			if keyConditionFunctions[node.Function.String()] {
+				continue
+			}
+
+			return invalidKeyConditionOperator(node.Function.String())
+		case *language.PrefixExpression:
+			return invalidKeyConditionOperator(node.Operator)
+		case *language.InExpression:
+			return invalidKeyConditionOperator(language.IN)
+		}
+
+		return fmt.Errorf("%w: invalid condition in %s: %s", ErrSyntaxError, KeyConditionExpression, term.String())
+	}
+
+	return nil
+}
+
+func invalidKeyConditionOperator(operator string) error {
+	return fmt.Errorf("%w: invalid operator used in %s: %s", ErrSyntaxError, KeyConditionExpression, operator)
+}
+
+// parseProjection parses the comma separated document paths of the projection, e.g. a.b[0], #c
+func parseProjection(src string) (*language.ProjectionExpression, error) {
+	l := language.NewLexer(src)
+	p := language.NewParser(l)
+	projection := p.ParseProjectionExpression()
+
+	if err := parserError(p); err != nil {
+		return nil, err
+	}
+
+	return projection, nil
+}
+
+func contains(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+
+	return false
+}
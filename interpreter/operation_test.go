@@ -0,0 +1,126 @@
+package interpreter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateForOperation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		op          string
+		exprs       map[string]string
+		expectedErr error
+		message     string
+	}{
+		{
+			name: "valid query",
+			op:   "Query",
+			exprs: map[string]string{
+				KeyConditionExpression: "#pk = :pk AND begins_with(sk, :prefix)",
+				FilterExpression:       "attribute_exists(active) OR kind IN (:a, :b)",
+				ProjectionExpression:   "id, profile.#n, items[0]",
+			},
+		},
+		{
+			name: "function in the key condition",
+			op:   "Query",
+			exprs: map[string]string{
+				KeyConditionExpression: "pk = :pk AND attribute_exists(sk)",
+			},
+			expectedErr: ErrSyntaxError,
+			message:     "syntax error: invalid operator used in KeyConditionExpression: attribute_exists",
+		},
+		{
+			name: "OR in the key condition",
+			op:   "Query",
+			exprs: map[string]string{
+				KeyConditionExpression: "pk = :pk OR pk = :other",
+			},
+			expectedErr: ErrSyntaxError,
+			message:     "syntax error: invalid operator used in KeyConditionExpression: OR",
+		},
+		{
+			name: "query without key condition",
+			op:   "Query",
+			exprs: map[string]string{
+				FilterExpression: "a = :a",
+			},
+			expectedErr: ErrSyntaxError,
+			message:     "syntax error: KeyConditionExpression is required by Query",
+		},
+		{
+			name: "filter in put item",
+			op:   "PutItem",
+			exprs: map[string]string{
+				FilterExpression: "a = :a",
+			},
+			expectedErr: ErrSyntaxError,
+			message:     "syntax error: FilterExpression is not supported by PutItem",
+		},
+		{
+			name: "several unsupported expressions",
+			op:   "GetItem",
+			exprs: map[string]string{
+				UpdateExpression:       "SET a = :a",
+				KeyConditionExpression: "pk = :pk",
+				FilterExpression:       "a = :a",
+				ConditionExpression:    "attribute_exists(pk)",
+			},
+			expectedErr: ErrSyntaxError,
+			message:     "syntax error: ConditionExpression is not supported by GetItem",
+		},
+		{
+			name: "valid update item",
+			op:   "UpdateItem",
+			exprs: map[string]string{
+				UpdateExpression:    "SET a = :a REMOVE b",
+				ConditionExpression: "attribute_exists(pk)",
+			},
+		},
+		{
+			name: "invalid update expression",
+			op:   "UpdateItem",
+			exprs: map[string]string{
+				UpdateExpression: "SET a",
+			},
+			expectedErr: ErrSyntaxError,
+		},
+		{
+			name: "invalid projection",
+			op:   "Scan",
+			exprs: map[string]string{
+				ProjectionExpression: "a, size(b)",
+			},
+			expectedErr: ErrSyntaxError,
+			message:     "syntax error: line 1, column 8: only attribute paths are allowed in the projection expression, got ( after size",
+		},
+		{
+			name: "value placeholder in projection",
+			op:   "GetItem",
+			exprs: map[string]string{
+				ProjectionExpression: ":v",
+			},
+			expectedErr: ErrSyntaxError,
+			message:     "syntax error: line 1, column 1: expected attribute path, got VALUE_PLACEHOLDER instead",
+		},
+		{
+			name:        "unknown operation",
+			op:          "TransactWriteItems",
+			expectedErr: ErrUnsupportedFeature,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateForOperation(tc.op, tc.exprs)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("unexpected error; expected=%v, got=%v", tc.expectedErr, err)
+			}
+
+			if tc.message != "" && err.Error() != tc.message {
+				t.Errorf("unexpected error message; expected=%q, got=%q", tc.message, err.Error())
+			}
+		})
+	}
+}
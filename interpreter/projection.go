@@ -0,0 +1,141 @@
+package interpreter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/truora/minidyn/interpreter/language"
+)
+
+// ProjectionFromStruct returns a ProjectionExpression selecting the attributes of the fields with
+// a dynamodbav tag and the expression attribute names it uses. The names that are reserved words
+// or are not valid in an expression are replaced by # placeholders. The fields of the embedded
+// structs without a tag are included as top level attributes, as dynamodbattribute would do
+func ProjectionFromStruct(v interface{}) (string, map[string]string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("ProjectionFromStruct requires a struct, got %v", reflect.TypeOf(v))
+	}
+
+	names := map[string]string{}
+	paths := []string{}
+
+	for _, attr := range structAttributes(t) {
+		if !IsReservedWord(attr) && isPlainAttributeName(attr) {
+			paths = append(paths, attr)
+			continue
+		}
+
+		placeholder := "#" + attr
+		if !isPlainAttributeName(attr) {
+			placeholder = fmt.Sprintf("#attr%d", len(names))
+		}
+
+		names[placeholder] = attr
+		paths = append(paths, placeholder)
+	}
+
+	return strings.Join(paths, ", "), names, nil
+}
+
+// structAttributes returns the attribute names of the tagged fields in declaration order
+func structAttributes(t reflect.Type) []string {
+	attrs := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, tagged := field.Tag.Lookup("dynamodbav")
+		name := strings.Split(tag, ",")[0]
+
+		if name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			attrs = append(attrs, structAttributes(fieldType)...)
+			continue
+		}
+
+		if !tagged || field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		attrs = append(attrs, name)
+	}
+
+	return attrs
+}
+
+// isPlainAttributeName whether the name can be used directly in an expression
+func isPlainAttributeName(name string) bool {
+	for i, ch := range name {
+		switch {
+		case ch == '_', 'a' <= ch && ch <= 'z', 'A' <= ch && ch <= 'Z':
+		case '0' <= ch && ch <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return name != ""
+}
+
+// FlattenProjection returns the values of the document paths in the item keyed by the path,
+// e.g. {"profile.name": ..., "orders[0].id": ...}. The paths missing in the item are omitted
+func FlattenProjection(item map[string]*dynamodb.AttributeValue, paths []string) (map[string]*dynamodb.AttributeValue, error) {
+	env := language.NewEnvironment()
+
+	err := env.AddAttributes(item)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFeature, err.Error())
+	}
+
+	flat := map[string]*dynamodb.AttributeValue{}
+
+	for _, src := range paths {
+		projection, err := parseProjection(src)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(projection.Paths) != 1 {
+			return nil, fmt.Errorf("%w: invalid document path in %s: %s", ErrSyntaxError, ProjectionExpression, strings.TrimSpace(src))
+		}
+
+		path := projection.Paths[0]
+
+		obj := language.Eval(path, env)
+		if obj.Type() == language.ObjectTypeError {
+			return nil, fmt.Errorf("%w: %s", ErrSyntaxError, obj.Inspect())
+		}
+
+		if obj == language.NULL {
+			continue
+		}
+
+		val, err := language.ObjectToAttributeValue(obj)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedFeature, err.Error())
+		}
+
+		flat[path.String()] = val
+	}
+
+	return flat, nil
+}
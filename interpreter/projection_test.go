@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type projectionAudit struct {
+	CreatedBy string `dynamodbav:"created_by"`
+	Hidden    string `dynamodbav:"-"`
+}
+
+type projectionAddress struct {
+	City string `dynamodbav:"city"`
+}
+
+type projectionUser struct {
+	projectionAudit
+	ID       string            `dynamodbav:"id"`
+	Name     string            `dynamodbav:"name"`
+	Status   string            `dynamodbav:"status,omitempty"`
+	Address  projectionAddress `dynamodbav:"address"`
+	Zip      string            `dynamodbav:"zip-code"`
+	Nickname string            `dynamodbav:",omitempty"`
+	Ignored  string            `dynamodbav:"-"`
+	Untagged string
+	internal string `dynamodbav:"internal"`
+}
+
+func TestProjectionFromStruct(t *testing.T) {
+	expected := "created_by, id, #name, #status, address, #attr2, Nickname"
+	expectedNames := map[string]string{
+		"#name":   "name",
+		"#status": "status",
+		"#attr2":  "zip-code",
+	}
+
+	for _, v := range []interface{}{projectionUser{}, &projectionUser{}} {
+		projection, names, err := ProjectionFromStruct(v)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if projection != expected {
+			t.Errorf("wrong projection. expected=%q, got=%q", expected, projection)
+		}
+
+		if !reflect.DeepEqual(names, expectedNames) {
+			t.Errorf("wrong names. expected=%v, got=%v", expectedNames, names)
+		}
+
+		err = ValidateForOperation("GetItem", map[string]string{ProjectionExpression: projection})
+		if err != nil {
+			t.Errorf("invalid projection: %s", err)
+		}
+	}
+}
+
+func TestProjectionFromStructErrors(t *testing.T) {
+	for _, v := range []interface{}{nil, "user", []projectionUser{}} {
+		_, _, err := ProjectionFromStruct(v)
+		if err == nil {
+			t.Errorf("expected an error for %#v", v)
+		}
+	}
+}
+
+func TestIsReservedWord(t *testing.T) {
+	tests := map[string]bool{
+		"status": true,
+		"Name":   true,
+		"COUNT":  true,
+		"id":     false,
+		"email":  false,
+	}
+
+	for word, expected := range tests {
+		if IsReservedWord(word) != expected {
+			t.Errorf("wrong reserved word check for %q. expected=%t", word, expected)
+		}
+	}
+}
+
+func TestFlattenProjection(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"id": {S: aws.String("1")},
+		"profile": {M: map[string]*dynamodb.AttributeValue{
+			"name": {S: aws.String("Juan")},
+			"address": {M: map[string]*dynamodb.AttributeValue{
+				"city": {S: aws.String("Bogota")},
+			}},
+		}},
+		"orders": {L: []*dynamodb.AttributeValue{
+			{M: map[string]*dynamodb.AttributeValue{"total": {N: aws.String("10")}}},
+			{M: map[string]*dynamodb.AttributeValue{"total": {N: aws.String("20")}}},
+		}},
+	}
+
+	flat, err := FlattenProjection(item, []string{"id", "profile.name", " profile.address.city", "orders[1].total", "orders[0]", "profile.missing", "orders[5].total"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]*dynamodb.AttributeValue{
+		"id":                   {S: aws.String("1")},
+		"profile.name":         {S: aws.String("Juan")},
+		"profile.address.city": {S: aws.String("Bogota")},
+		"orders[1].total":      {N: aws.String("20")},
+		"orders[0]":            {M: map[string]*dynamodb.AttributeValue{"total": {N: aws.String("10")}}},
+	}
+
+	if !reflect.DeepEqual(flat, expected) {
+		t.Errorf("wrong flat projection. expected=%v, got=%v", expected, flat)
+	}
+}
+
+func TestFlattenProjectionInvalidPath(t *testing.T) {
+	for _, path := range []string{"a = :b", ":v", "a, b", ""} {
+		_, err := FlattenProjection(map[string]*dynamodb.AttributeValue{}, []string{path})
+		if !errors.Is(err, ErrSyntaxError) {
+			t.Errorf("expected a syntax error for %q, got=%v", path, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/truora/minidyn/interpreter/language"
+)
+
+// Update a compiled update expression ready to be applied to the items
+type Update struct {
+	expression *language.UpdateExpression
+}
+
+// CompileUpdate parses the update expression, the result can be applied to many items
+func CompileUpdate(src string) (*Update, error) {
+	l := language.NewLexer(src)
+	p := language.NewParser(l)
+	expression := p.ParseUpdateExpression()
+
+	if err := parserError(p); err != nil {
+		return nil, err
+	}
+
+	return &Update{expression: expression}, nil
+}
+
+// String returns the update expression
+func (u *Update) String() string {
+	return u.expression.String()
+}
+
+// Apply returns a new item with all the actions of the update expression applied,
+// the given item is never modified
+func (u *Update) Apply(item map[string]*dynamodb.AttributeValue, names map[string]*string, values map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+	err := validatePlaceholders(u.expression, names, values)
+	if err != nil {
+		return nil, err
+	}
+
+	env := language.NewEnvironment()
+	env.Aliases = expressionAliases(names)
+
+	err = env.AddAttributes(item)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFeature, err.Error())
+	}
+
+	err = env.AddAttributes(values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFeature, err.Error())
+	}
+
+	result := language.EvalUpdate(u.expression, env)
+	if result.Type() == language.ObjectTypeError {
+		return nil, fmt.Errorf("%w: %s", ErrSyntaxError, result.Inspect())
+	}
+
+	updated := map[string]*dynamodb.AttributeValue{}
+
+	for name, obj := range env.Attributes() {
+		if _, ok := values[name]; ok {
+			continue
+		}
+
+		val, err := language.ObjectToAttributeValue(obj)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedFeature, err.Error())
+		}
+
+		updated[name] = val
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,167 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestCompileUpdate(t *testing.T) {
+	update, err := CompileUpdate("SET #n = :name, kind = :kind REMOVE old ADD visits :one, tags :new DELETE colors :red")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	item := map[string]*dynamodb.AttributeValue{
+		"id":     {S: aws.String("001")},
+		"name":   {S: aws.String("Juan")},
+		"old":    {BOOL: aws.Bool(true)},
+		"visits": {N: aws.String("2")},
+		"colors": {SS: aws.StringSlice([]string{"blue", "red"})},
+	}
+
+	names := map[string]*string{
+		"#n": aws.String("name"),
+	}
+
+	values := map[string]*dynamodb.AttributeValue{
+		":name": {S: aws.String("Pedro")},
+		":kind": {N: aws.String("1.5")},
+		":one":  {N: aws.String("1")},
+		":new":  {SS: aws.StringSlice([]string{"b", "a"})},
+		":red":  {SS: aws.StringSlice([]string{"red"})},
+	}
+
+	actual, err := update.Apply(item, names, values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]*dynamodb.AttributeValue{
+		"id":     {S: aws.String("001")},
+		"name":   {S: aws.String("Pedro")},
+		"kind":   {N: aws.String("1.5")},
+		"visits": {N: aws.String("3")},
+		"tags":   {SS: aws.StringSlice([]string{"a", "b"})},
+		"colors": {SS: aws.StringSlice([]string{"blue"})},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected item; expected=%v, got=%v", expected, actual)
+	}
+
+	if aws.StringValue(item["name"].S) != "Juan" || item["old"] == nil || len(item) != 5 {
+		t.Errorf("the original item was modified; got=%v", item)
+	}
+}
+
+func TestCompileUpdateErrors(t *testing.T) {
+	_, err := CompileUpdate("SET a")
+	if !errors.Is(err, ErrSyntaxError) {
+		t.Errorf("unexpected error; expected=%v, got=%v", ErrSyntaxError, err)
+	}
+
+	expected := "syntax error: line 1, column 6: expected next token to be =, got EOF instead"
+	if err == nil || err.Error() != expected {
+		t.Errorf("the error must include the position; expected=%q, got=%v", expected, err)
+	}
+
+	update, err := CompileUpdate("ADD visits :one")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = update.Apply(
+		map[string]*dynamodb.AttributeValue{"visits": {S: aws.String("two")}},
+		nil,
+		map[string]*dynamodb.AttributeValue{":one": {N: aws.String("1")}},
+	)
+	if !errors.Is(err, ErrSyntaxError) {
+		t.Errorf("unexpected error; expected=%v, got=%v", ErrSyntaxError, err)
+	}
+}
+
+func TestUpdateApplyDoesNotModifyTheItem(t *testing.T) {
+	update, err := CompileUpdate("SET profile.address.city = :city, profile.tags[0] = :tag, history[2] = :tag REMOVE profile.age, history[0]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	newItem := func() map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"id": {S: aws.String("001")},
+			"profile": {M: map[string]*dynamodb.AttributeValue{
+				"age": {N: aws.String("30")},
+				"address": {M: map[string]*dynamodb.AttributeValue{
+					"city": {S: aws.String("Bogota")},
+				}},
+				"tags": {L: []*dynamodb.AttributeValue{{S: aws.String("a")}}},
+			}},
+			"history": {L: []*dynamodb.AttributeValue{{S: aws.String("first")}, {S: aws.String("second")}}},
+		}
+	}
+
+	item := newItem()
+
+	values := map[string]*dynamodb.AttributeValue{
+		":city": {S: aws.String("Medellin")},
+		":tag":  {S: aws.String("b")},
+	}
+
+	actual, err := update.Apply(item, nil, values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(item, newItem()) {
+		t.Errorf("the original item was modified; got=%v", item)
+	}
+
+	expected := map[string]*dynamodb.AttributeValue{
+		"id": {S: aws.String("001")},
+		"profile": {M: map[string]*dynamodb.AttributeValue{
+			"address": {M: map[string]*dynamodb.AttributeValue{
+				"city": {S: aws.String("Medellin")},
+			}},
+			"tags": {L: []*dynamodb.AttributeValue{{S: aws.String("b")}}},
+		}},
+		"history": {L: []*dynamodb.AttributeValue{{S: aws.String("second")}, {S: aws.String("b")}}},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected item; expected=%v, got=%v", expected, actual)
+	}
+}
+
+func TestUpdateApplyDecimalNumbers(t *testing.T) {
+	update, err := CompileUpdate("ADD balance :amount, views :one")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	actual, err := update.Apply(
+		map[string]*dynamodb.AttributeValue{
+			"balance": {N: aws.String("0.1")},
+			"views":   {N: aws.String("12345678901234567890123456789")},
+		},
+		nil,
+		map[string]*dynamodb.AttributeValue{
+			":amount": {N: aws.String("0.20")},
+			":one":    {N: aws.String("1")},
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if balance := aws.StringValue(actual["balance"].N); balance != "0.3" {
+		t.Errorf("wrong balance; expected=%q, got=%q", "0.3", balance)
+	}
+
+	if views := aws.StringValue(actual["views"].N); views != "12345678901234567890123456790" {
+		t.Errorf("wrong views; expected=%q, got=%q", "12345678901234567890123456790", views)
+	}
+}
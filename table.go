@@ -1,6 +1,7 @@
 package minidyn
 
 import (
+	"errors"
 	"sort"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -429,14 +430,26 @@ func (t *table) put(input *dynamodb.PutItemInput) (map[string]*dynamodb.Attribut
 	return item, nil
 }
 
-func (t *table) interpreterUpdate(input interpreter.UpdateInput) {
+// interpreterUpdate uses the native interpreter only for the features not supported by the
+// language interpreter, the invalid updates are validation errors as in DynamoDB
+func (t *table) interpreterUpdate(input interpreter.UpdateInput) error {
 	err := t.langInterpreter.Update(input)
-	if err != nil {
-		nativeErr := t.nativeInterpreter.Update(input)
-		if nativeErr != nil {
-			panic(nativeErr)
-		}
+	if err == nil {
+		return nil
 	}
+
+	if !errors.Is(err, interpreter.ErrUnsupportedFeature) {
+		code, msg := interpreter.AsAWSError(err)
+
+		return awserr.New(code, msg, nil)
+	}
+
+	nativeErr := t.nativeInterpreter.Update(input)
+	if nativeErr != nil {
+		panic(nativeErr)
+	}
+
+	return nil
 }
 
 func (t *table) update(input *dynamodb.UpdateItemInput) (map[string]*dynamodb.AttributeValue, error) {
@@ -474,12 +487,16 @@ func (t *table) update(input *dynamodb.UpdateItemInput) (map[string]*dynamodb.At
 
 	oldItem := copyItem(item)
 
-	t.interpreterUpdate(interpreter.UpdateInput{
+	err := t.interpreterUpdate(interpreter.UpdateInput{
 		TableName:  t.name,
 		Expression: aws.StringValue(input.UpdateExpression),
 		Item:       item,
 		Attributes: input.ExpressionAttributeValues,
+		Aliases:    input.ExpressionAttributeNames,
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	t.setItem(key, item)
 